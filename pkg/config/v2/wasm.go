@@ -0,0 +1,48 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+// WasmPluginConfig is the user-facing configuration for a single wasm plugin, as parsed
+// from the "wasm_global_plugins" section of the MOSN config file.
+type WasmPluginConfig struct {
+	PluginName  string        `json:"plugin_name"`
+	VmConfig    *WasmVmConfig `json:"vm_config"`
+	InstanceNum int           `json:"instance_num,omitempty"`
+
+	// DrainTimeoutMs bounds how long AddOrUpdateWasm keeps a superseded plugin's instances
+	// alive waiting for their outstanding contexts to finish, before forcing release. Zero
+	// means the wasm package's default.
+	DrainTimeoutMs int64 `json:"drain_timeout_ms,omitempty"`
+}
+
+// WasmVmConfig describes how to load and run the wasm binary backing a plugin.
+type WasmVmConfig struct {
+	Engine string `json:"engine,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Code   []byte `json:"-"`
+
+	// AbiVersion selects which proxy-wasm ABI the module is built against, e.g.
+	// "proxy_abi_version_0_1_0" or "proxy_abi_version_0_2_0". When empty, MOSN defaults to
+	// 0.1.0 for compatibility with existing plugins; it does not probe the module's exports
+	// to infer the version.
+	AbiVersion string `json:"abi_version,omitempty"`
+
+	// Address is the dial target of an out-of-process wasm sidecar, e.g. "unix:///var/run/
+	// wasm-sidecar.sock" or "127.0.0.1:9700". Only meaningful when Engine is "remote".
+	Address string `json:"address,omitempty"`
+}
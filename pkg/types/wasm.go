@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+// WasmVM abstracts an underlying wasm virtual machine engine, e.g. wasmer or wazero.
+// A concrete engine registers itself via wasm/runtime so that WasmPluginWrapper can
+// instantiate modules without depending on the engine package directly.
+type WasmVM interface {
+	// Name returns the engine name, used as the VmConfig.Engine value that selects it.
+	Name() string
+
+	// Init prepares the engine for use, e.g. allocating the compiler/store.
+	Init() error
+
+	// NewModule compiles wasmBytes into a WasmModule bound to the given proxy-wasm ABI
+	// version (e.g. "proxy_abi_version_0_1_0"). Returns nil on compile failure or an
+	// unknown abiVersion.
+	NewModule(wasmBytes []byte, abiVersion string) WasmModule
+
+	// Close releases any engine-wide resources.
+	Close()
+}
+
+// AddressedWasmVM is implemented by engines whose modules are loaded from an out-of-process
+// endpoint rather than compiled in-process (currently just the "remote" engine). wasm.
+// AddOrUpdateWasm calls NewModuleAt instead of NewModule when an engine implements this,
+// passing WasmVmConfig.Address through without widening NewModule's signature - and
+// therefore every other engine - for a detail only one of them needs.
+type AddressedWasmVM interface {
+	// NewModuleAt behaves like WasmVM.NewModule, but dials address for the out-of-process
+	// runtime hosting the module instead of compiling wasmBytes in this process.
+	NewModuleAt(address string, wasmBytes []byte, abiVersion string) WasmModule
+}
+
+// WasmModule is a compiled wasm binary that can be instantiated many times.
+type WasmModule interface {
+	// NewInstance creates a new, unstarted WasmInstance sharing this module's compiled code.
+	NewInstance() WasmInstance
+
+	// Close releases the compiled module.
+	Close()
+}
+
+// WasmInstance is a single instantiation of a WasmModule, with its own linear memory and
+// globals. Instances are pooled by WasmPlugin and must be Acquire()'d before use and
+// Release()'d afterwards so that concurrent callers don't race on the same linear memory.
+type WasmInstance interface {
+	// Acquire binds abi to this instance for the duration of a call and locks the instance.
+	// Returns false if the instance has already been stopped.
+	Acquire(abi ABI) bool
+
+	// Release unlocks the instance after a call completes.
+	Release()
+
+	// Start runs the module's start function, if any.
+	Start() error
+
+	// Stop tears down the instance; it must not be reused afterwards.
+	Stop()
+
+	// GetModule returns the module this instance was created from.
+	GetModule() WasmModule
+
+	// CallExportsFunc invokes the named exported function with the given int32 arguments
+	// and returns its single int32 result, as used by the proxy_on_* exports calling
+	// convention.
+	CallExportsFunc(name string, args ...int32) (int32, error)
+
+	// GetMemory reads size bytes of linear memory starting at addr.
+	GetMemory(addr uint64, size uint64) ([]byte, error)
+
+	// PutMemory writes content into linear memory starting at addr.
+	PutMemory(addr uint64, content []byte) error
+
+	// Malloc calls the module's exported allocator and returns the allocated address.
+	Malloc(size int32) (uint64, error)
+
+	// GetData/SetData carry the ABI-specific per-instance binding, e.g. the
+	// proxywasm exports wrapper currently bound to this instance.
+	GetData() interface{}
+	SetData(data interface{})
+}
+
+// ABI binds a specific proxy-wasm ABI version (e.g. 0.1.0, 0.2.0) to a WasmInstance,
+// translating between the host's Go-level ImportsHandler/Exports and the instance's
+// linear memory and exported functions.
+type ABI interface {
+	// Name returns the ABI version string this implementation satisfies, e.g. "proxy_abi_version_0_1_0".
+	Name() string
+
+	// SetImports registers the host-side callback implementation the wasm module will call into.
+	SetImports(imports interface{})
+
+	// GetImports returns the previously registered imports.
+	GetImports() interface{}
+
+	// SetInstance binds this ABI to instance so exported/imported calls can reach its memory.
+	SetInstance(instance WasmInstance)
+
+	// GetInstance returns the bound instance.
+	GetInstance() WasmInstance
+
+	// GetExports returns the ABI-specific exports interface, to be type-asserted by the caller,
+	// e.g. `abi.GetExports().(proxywasm_0_1_0.Exports)`.
+	GetExports() interface{}
+}
@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package abi is the registry of proxy-wasm ABI implementations. A concrete ABI version,
+// e.g. pkg/wasm/abi/proxywasm_0_1_0, registers a constructor here via RegisterABI; callers
+// then look it up by name with GetABI without importing the version package directly.
+package abi
+
+import (
+	"sync"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+var (
+	abiMux   sync.RWMutex
+	abiAlloc = make(map[string]func(instance types.WasmInstance) types.ABI)
+)
+
+// RegisterABI registers a constructor for the named ABI version. It is expected to be
+// called from the init() of each proxywasm_x_y_z package.
+func RegisterABI(name string, f func(instance types.WasmInstance) types.ABI) {
+	abiMux.Lock()
+	defer abiMux.Unlock()
+
+	abiAlloc[name] = f
+}
+
+// GetABI returns a new ABI bound to instance for the given abi version name. It returns
+// nil if no such ABI version has been registered.
+func GetABI(instance types.WasmInstance, name string) types.ABI {
+	abiMux.RLock()
+	f, ok := abiAlloc[name]
+	abiMux.RUnlock()
+
+	if !ok {
+		log.DefaultLogger.Errorf("[wasm][abi] GetABI: unknown abi version %s", name)
+		return nil
+	}
+
+	abi := f(instance)
+	abi.SetInstance(instance)
+	return abi
+}
@@ -0,0 +1,397 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxywasm_0_1_0
+
+import (
+	"fmt"
+	"sync"
+
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// CallHeaders is the minimal key/value view an HTTP or gRPC callout exposes for its
+// headers/trailers/metadata; it is intentionally narrower than api.HeaderMap since callout
+// responses are host-internal state, not stream state owned by a filter.
+type CallHeaders map[string]string
+
+func (c CallHeaders) Get(key string) (string, bool) {
+	v, ok := c[key]
+	return v, ok
+}
+
+// HttpCalloutCallback is invoked once a proxy_http_call dispatched through HttpCallout
+// completes, with the token Dispatch returns to its caller and the upstream's response.
+// Dispatch must pass the very token it hands back - the callback has no other reliable way
+// to learn it, since nothing stops two callouts from the same context being outstanding at
+// once.
+type HttpCalloutCallback func(token uint32, headers CallHeaders, body buffer.IoBuffer, trailers CallHeaders)
+
+// HttpCallout issues an outgoing HTTP request to a MOSN upstream cluster on behalf of a
+// wasm module, without blocking the calling context. A production ImportsHandler backs
+// this with MOSN's existing cluster manager; tests can fake it directly.
+type HttpCallout interface {
+	// Dispatch sends an HTTP request to cluster and invokes cb asynchronously when the
+	// response (or an error) arrives. The returned token correlates the eventual
+	// ProxyOnHttpCallResponse back to this call.
+	Dispatch(cluster string, headers CallHeaders, body buffer.IoBuffer, trailers CallHeaders, timeoutMillisecond uint32, cb HttpCalloutCallback) (token uint32, err error)
+
+	// Cancel aborts an in-flight call; cb will not be invoked afterwards.
+	Cancel(token uint32) error
+}
+
+// GrpcCalloutCallback is invoked for each message received on a gRPC callout, with the token
+// Open returned (see HttpCalloutCallback for why that can't be reconstructed some other way):
+// once with the initial metadata via GrpcReceiveInitialMetadata semantics is out of scope
+// here, data carries each received message, and trailers/status are delivered with the final
+// message.
+type GrpcCalloutCallback func(token uint32, data buffer.IoBuffer, trailers CallHeaders, grpcStatus int32)
+
+// GrpcCallout issues outgoing gRPC calls to a MOSN upstream cluster on behalf of a wasm
+// module. It mirrors the open/send/cancel/close primitives of the proxy-wasm gRPC ABI:
+// Open starts a stream (or, for a single send immediately followed by Close, a unary
+// call), Send writes one message, Cancel aborts it, and Close half-closes the send side.
+type GrpcCallout interface {
+	Open(cluster string, serviceName string, method string, initialMetadata CallHeaders, timeoutMillisecond uint32, cb GrpcCalloutCallback) (token uint32, err error)
+	Send(token uint32, message buffer.IoBuffer, endOfStream bool) error
+	Cancel(token uint32) error
+	Close(token uint32) error
+}
+
+// calloutState lets the reentrant ProxyOnHttpCallResponse/ProxyOnGrpcReceive serve
+// proxy_get_buffer_bytes/proxy_get_map_value calls for the response currently being
+// delivered to the module, one instance at a time.
+type calloutState struct {
+	mu sync.Mutex
+
+	active *calloutResponse // the response currently being delivered to the module, if any
+}
+
+type calloutResponse struct {
+	token    uint32
+	headers  CallHeaders
+	body     buffer.IoBuffer
+	trailers CallHeaders
+}
+
+var calloutStates sync.Map // types.WasmInstance -> *calloutState
+
+func stateFor(instance types.WasmInstance) *calloutState {
+	v, _ := calloutStates.LoadOrStore(instance, &calloutState{})
+	return v.(*calloutState)
+}
+
+// ReleaseCallouts drops instance's entry from calloutStates. Callers stopping an instance for
+// good (wasmPlugin.Close) must call this, or every instance a plugin ever creates over the
+// process lifetime - including every generation a reload leaves behind - leaks one entry here
+// forever.
+func ReleaseCallouts(instance types.WasmInstance) {
+	calloutStates.Delete(instance)
+}
+
+func (s *calloutState) withActive(resp *calloutResponse, f func()) {
+	s.mu.Lock()
+	s.active = resp
+	s.mu.Unlock()
+
+	f()
+
+	s.mu.Lock()
+	s.active = nil
+	s.mu.Unlock()
+}
+
+func proxyHttpCall(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	callout := handler.GetHttpCallout()
+	if callout == nil {
+		return WasmResultNotFound
+	}
+
+	clusterAddr, clusterSize := args[0], args[1]
+	headerAddr, headerSize := args[2], args[3]
+	bodyAddr, bodySize := args[4], args[5]
+	trailerAddr, trailerSize := args[6], args[7]
+	timeoutMillisecond := args[8]
+	returnTokenAddr := args[9]
+
+	cluster, err := readString(instance, clusterAddr, clusterSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	headers, err := readCallHeaders(instance, headerAddr, headerSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	trailers, err := readCallHeaders(instance, trailerAddr, trailerSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	body, err := instance.GetMemory(uint64(bodyAddr), uint64(bodySize))
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	rootContextID := handler.GetRootContextID()
+	state := stateFor(instance)
+
+	token, err := callout.Dispatch(cluster, headers, buffer.NewIoBufferBytes(body), trailers, uint32(timeoutMillisecond),
+		func(respToken uint32, respHeaders CallHeaders, respBody buffer.IoBuffer, respTrailers CallHeaders) {
+			onHttpCallResponse(instance, state, rootContextID, respToken, respHeaders, respBody, respTrailers)
+		})
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	if err := instance.PutMemory(uint64(returnTokenAddr), encodeUint32(token)); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+// onHttpCallResponse re-enters the module on behalf of an upstream response that arrived
+// asynchronously. It must be called on the goroutine that owns instance's lock: callers
+// dispatch through ImportsHandler.GetHttpCallout(), whose production implementation hands
+// off back onto MOSN's instance-owning worker before invoking this.
+func onHttpCallResponse(instance types.WasmInstance, state *calloutState, contextID int32, token uint32, headers CallHeaders, body buffer.IoBuffer, trailers CallHeaders) {
+	resp := &calloutResponse{token: token, headers: headers, body: body, trailers: trailers}
+
+	numHeaders := int32(len(headers))
+	numTrailers := int32(len(trailers))
+	bodySize := int32(0)
+	if body != nil {
+		bodySize = int32(body.Len())
+	}
+
+	state.withActive(resp, func() {
+		_, _ = instance.CallExportsFunc("proxy_on_http_call_response", contextID, int32(token), numHeaders, bodySize, numTrailers)
+	})
+}
+
+func proxyGrpcCall(instance types.WasmInstance, args ...int32) int32 {
+	return openGrpcCall(instance, args, true)
+}
+
+func proxyGrpcStream(instance types.WasmInstance, args ...int32) int32 {
+	return openGrpcCall(instance, args, false)
+}
+
+func openGrpcCall(instance types.WasmInstance, args []int32, unary bool) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	callout := handler.GetGrpcCallout()
+	if callout == nil {
+		return WasmResultNotFound
+	}
+
+	clusterAddr, clusterSize := args[0], args[1]
+	serviceAddr, serviceSize := args[2], args[3]
+	methodAddr, methodSize := args[4], args[5]
+	metadataAddr, metadataSize := args[6], args[7]
+
+	var messageAddr, messageSize, timeoutMillisecond, returnTokenAddr int32
+	if unary {
+		messageAddr, messageSize = args[8], args[9]
+		timeoutMillisecond = args[10]
+		returnTokenAddr = args[11]
+	} else {
+		timeoutMillisecond = args[8]
+		returnTokenAddr = args[9]
+	}
+
+	cluster, err := readString(instance, clusterAddr, clusterSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+	service, err := readString(instance, serviceAddr, serviceSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+	method, err := readString(instance, methodAddr, methodSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+	metadata, err := readCallHeaders(instance, metadataAddr, metadataSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	rootContextID := handler.GetRootContextID()
+	state := stateFor(instance)
+
+	token, err := callout.Open(cluster, service, method, metadata, uint32(timeoutMillisecond),
+		func(respToken uint32, data buffer.IoBuffer, trailers CallHeaders, grpcStatus int32) {
+			onGrpcReceive(instance, state, rootContextID, respToken, data, trailers, grpcStatus)
+		})
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	if unary {
+		message, err := instance.GetMemory(uint64(messageAddr), uint64(messageSize))
+		if err != nil {
+			return WasmResultBadArgument
+		}
+		if err := callout.Send(token, buffer.NewIoBufferBytes(message), true); err != nil {
+			return WasmResultInternalFailure
+		}
+	}
+
+	if err := instance.PutMemory(uint64(returnTokenAddr), encodeUint32(token)); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+func proxyGrpcSend(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+	callout := handler.GetGrpcCallout()
+	if callout == nil {
+		return WasmResultNotFound
+	}
+
+	token, messageAddr, messageSize, endOfStream := uint32(args[0]), args[1], args[2], args[3]
+
+	message, err := instance.GetMemory(uint64(messageAddr), uint64(messageSize))
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	if err := callout.Send(token, buffer.NewIoBufferBytes(message), endOfStream != 0); err != nil {
+		return WasmResultInternalFailure
+	}
+	return WasmResultOk
+}
+
+func proxyGrpcCancel(instance types.WasmInstance, args ...int32) int32 {
+	return grpcTerminate(instance, args, func(c GrpcCallout, token uint32) error { return c.Cancel(token) })
+}
+
+func proxyGrpcClose(instance types.WasmInstance, args ...int32) int32 {
+	return grpcTerminate(instance, args, func(c GrpcCallout, token uint32) error { return c.Close(token) })
+}
+
+func grpcTerminate(instance types.WasmInstance, args []int32, f func(GrpcCallout, uint32) error) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+	callout := handler.GetGrpcCallout()
+	if callout == nil {
+		return WasmResultNotFound
+	}
+
+	token := uint32(args[0])
+	if err := f(callout, token); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+func onGrpcReceive(instance types.WasmInstance, state *calloutState, contextID int32, token uint32, data buffer.IoBuffer, trailers CallHeaders, grpcStatus int32) {
+	resp := &calloutResponse{token: token, body: data, trailers: trailers}
+
+	size := int32(0)
+	if data != nil {
+		size = int32(data.Len())
+	}
+
+	state.withActive(resp, func() {
+		_, _ = instance.CallExportsFunc("proxy_on_grpc_receive", contextID, int32(token), size)
+		if grpcStatus >= 0 {
+			_, _ = instance.CallExportsFunc("proxy_on_grpc_close", contextID, int32(token), grpcStatus)
+		}
+	})
+}
+
+func readString(instance types.WasmInstance, addr int32, size int32) (string, error) {
+	b, err := instance.GetMemory(uint64(addr), uint64(size))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readCallHeaders decodes the proxy-wasm wire format for a header map: a little-endian
+// uint32 pair count, followed by (key_size, value_size) uint32 pairs, followed by each
+// NUL-terminated key and value in turn.
+func readCallHeaders(instance types.WasmInstance, addr int32, size int32) (CallHeaders, error) {
+	if size == 0 {
+		return CallHeaders{}, nil
+	}
+
+	raw, err := instance.GetMemory(uint64(addr), uint64(size))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) < 4 {
+		return nil, fmt.Errorf("proxywasm_0_1_0: truncated header map")
+	}
+
+	count := decodeUint32(raw[0:4])
+	offset := 4 + int(count)*8
+	if offset > len(raw) {
+		return nil, fmt.Errorf("proxywasm_0_1_0: header map count %d overruns %d-byte buffer", count, len(raw))
+	}
+	sizes := raw[4:offset]
+
+	headers := make(CallHeaders, count)
+	pos := offset
+	for i := uint32(0); i < count; i++ {
+		keySize := decodeUint32(sizes[i*8 : i*8+4])
+		valueSize := decodeUint32(sizes[i*8+4 : i*8+8])
+
+		// pos+keySize+1+valueSize+1 is computed in int, which is 64-bit on every platform
+		// this runs on, so it can't itself overflow even for an adversarial uint32 size; the
+		// bounds check below is what actually rejects an out-of-range entry.
+		end := pos + int(keySize) + 1 + int(valueSize) + 1
+		if end > len(raw) {
+			return nil, fmt.Errorf("proxywasm_0_1_0: header map entry %d overruns %d-byte buffer", i, len(raw))
+		}
+
+		key := string(raw[pos : pos+int(keySize)])
+		pos += int(keySize) + 1 // skip NUL terminator
+		value := string(raw[pos : pos+int(valueSize)])
+		pos += int(valueSize) + 1
+
+		headers[key] = value
+	}
+
+	return headers, nil
+}
+
+func decodeUint32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
@@ -0,0 +1,356 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxywasm_0_1_0
+
+import (
+	"fmt"
+	"testing"
+
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// fakeInstance is a minimal in-process types.WasmInstance: linear memory is a plain byte
+// slice and CallExportsFunc just records the call, so the callout host functions can be
+// exercised without a real wasm engine or module.
+type fakeInstance struct {
+	mem      []byte
+	next     uint64
+	data     interface{}
+	recorded []recordedCall
+}
+
+type recordedCall struct {
+	name string
+	args []int32
+}
+
+func newFakeInstance() *fakeInstance {
+	return &fakeInstance{mem: make([]byte, 4096), next: 1024}
+}
+
+func (f *fakeInstance) Acquire(abi types.ABI) bool  { return true }
+func (f *fakeInstance) Release()                    {}
+func (f *fakeInstance) Start() error                { return nil }
+func (f *fakeInstance) Stop()                       {}
+func (f *fakeInstance) GetModule() types.WasmModule { return nil }
+
+func (f *fakeInstance) CallExportsFunc(name string, args ...int32) (int32, error) {
+	f.recorded = append(f.recorded, recordedCall{name: name, args: args})
+	return 0, nil
+}
+
+func (f *fakeInstance) GetMemory(addr uint64, size uint64) ([]byte, error) {
+	if addr+size > uint64(len(f.mem)) {
+		return nil, fmt.Errorf("out of bounds")
+	}
+	out := make([]byte, size)
+	copy(out, f.mem[addr:addr+size])
+	return out, nil
+}
+
+func (f *fakeInstance) PutMemory(addr uint64, content []byte) error {
+	if addr+uint64(len(content)) > uint64(len(f.mem)) {
+		return fmt.Errorf("out of bounds")
+	}
+	copy(f.mem[addr:], content)
+	return nil
+}
+
+func (f *fakeInstance) Malloc(size int32) (uint64, error) {
+	addr := f.next
+	f.next += uint64(size)
+	return addr, nil
+}
+
+func (f *fakeInstance) GetData() interface{}     { return f.data }
+func (f *fakeInstance) SetData(data interface{}) { f.data = data }
+
+// writeString writes s at addr and returns (addr, size).
+func (f *fakeInstance) writeString(addr uint64, s string) (int32, int32) {
+	_ = f.PutMemory(addr, []byte(s))
+	return int32(addr), int32(len(s))
+}
+
+// writeHeaders encodes headers in the proxy-wasm wire format at addr and returns (addr, size).
+func (f *fakeInstance) writeHeaders(addr uint64, headers map[string]string) (int32, int32) {
+	buf := encodeUint32(uint32(len(headers)))
+	var keys, values []string
+	for k, v := range headers {
+		keys = append(keys, k)
+		values = append(values, v)
+		buf = append(buf, encodeUint32(uint32(len(k)))...)
+		buf = append(buf, encodeUint32(uint32(len(v)))...)
+	}
+	for i := range keys {
+		buf = append(buf, []byte(keys[i])...)
+		buf = append(buf, 0)
+		buf = append(buf, []byte(values[i])...)
+		buf = append(buf, 0)
+	}
+	_ = f.PutMemory(addr, buf)
+	return int32(addr), int32(len(buf))
+}
+
+// fakeCluster is a fake upstream cluster manager: it stores every request it was asked to
+// dispatch and lets the test trigger the callback explicitly to simulate an async response
+// arriving, including a chunked body.
+type fakeCluster struct {
+	httpToken uint32
+	lastBody  []byte
+	lastHdrs  CallHeaders
+	lastTrl   CallHeaders
+}
+
+func (c *fakeCluster) Dispatch(cluster string, headers CallHeaders, body buffer.IoBuffer, trailers CallHeaders, timeoutMillisecond uint32, cb HttpCalloutCallback) (uint32, error) {
+	c.lastHdrs = headers
+	c.lastTrl = trailers
+	if body != nil {
+		c.lastBody = body.Bytes()
+	}
+	c.httpToken++
+	token := c.httpToken
+
+	// simulate the upstream response arriving in two chunks, as a real cluster stream would.
+	respBody := buffer.NewIoBufferString("chunk-1")
+	respBody.Append([]byte("chunk-2"))
+
+	cb(token, CallHeaders{":status": "200"}, respBody, CallHeaders{"x-trailer": "done"})
+	return token, nil
+}
+
+func (c *fakeCluster) Cancel(token uint32) error { return nil }
+
+func TestProxyHttpCallDispatchesAndRespondsWithChunkedBody(t *testing.T) {
+	instance := newFakeInstance()
+	cluster := &fakeCluster{}
+
+	handler := &calloutTestHandler{
+		DefaultInstanceCallback: DefaultInstanceCallback{},
+		httpCallout:             cluster,
+	}
+	instance.SetData(handler)
+
+	clusterAddr, clusterSize := instance.writeString(0, "fake_cluster")
+	headerAddr, headerSize := instance.writeHeaders(64, map[string]string{"x-req": "1"})
+	bodyAddr, bodySize := instance.writeString(256, "request body")
+	trailerAddr, trailerSize := instance.writeHeaders(320, map[string]string{"x-req-trailer": "2"})
+
+	returnTokenAddr := int32(900)
+
+	result := proxyHttpCall(instance, clusterAddr, clusterSize, headerAddr, headerSize, bodyAddr, bodySize, trailerAddr, trailerSize, 1000, returnTokenAddr)
+	if result != WasmResultOk {
+		t.Fatalf("proxyHttpCall: want WasmResultOk, got %d", result)
+	}
+
+	if len(cluster.lastBody) == 0 || string(cluster.lastBody) != "request body" {
+		t.Errorf("cluster did not receive the request body: %q", cluster.lastBody)
+	}
+	if v, _ := cluster.lastHdrs.Get("x-req"); v != "1" {
+		t.Errorf("cluster did not receive request headers: %v", cluster.lastHdrs)
+	}
+	if v, _ := cluster.lastTrl.Get("x-req-trailer"); v != "2" {
+		t.Errorf("cluster did not receive request trailers: %v", cluster.lastTrl)
+	}
+
+	tokenBytes, err := instance.GetMemory(uint64(returnTokenAddr), 4)
+	if err != nil {
+		t.Fatalf("read back token: %v", err)
+	}
+	token := decodeUint32(tokenBytes)
+	if token == 0 {
+		t.Errorf("expected a non-zero token")
+	}
+
+	if len(instance.recorded) != 1 || instance.recorded[0].name != "proxy_on_http_call_response" {
+		t.Fatalf("expected a single proxy_on_http_call_response call, got %+v", instance.recorded)
+	}
+
+	call := instance.recorded[0]
+	gotContextID, gotToken, gotNumHeaders, gotBodySize, gotNumTrailers := call.args[0], call.args[1], call.args[2], call.args[3], call.args[4]
+
+	if gotToken != int32(token) {
+		t.Errorf("proxy_on_http_call_response token = %d, want %d", gotToken, token)
+	}
+	if gotContextID != handler.GetRootContextID() {
+		t.Errorf("proxy_on_http_call_response contextID = %d, want %d", gotContextID, handler.GetRootContextID())
+	}
+	if gotNumHeaders != 1 {
+		t.Errorf("proxy_on_http_call_response numHeaders = %d, want 1", gotNumHeaders)
+	}
+	if gotNumTrailers != 1 {
+		t.Errorf("proxy_on_http_call_response numTrailers = %d, want 1", gotNumTrailers)
+	}
+	if gotBodySize != int32(len("chunk-1chunk-2")) {
+		t.Errorf("proxy_on_http_call_response bodySize = %d, want %d (chunked body not fully buffered)", gotBodySize, len("chunk-1chunk-2"))
+	}
+}
+
+// fakeGrpcCluster is a fake upstream gRPC cluster manager: it records the stream Open was
+// asked for and every message Send writes, and lets the test trigger the stored callback
+// explicitly to simulate messages (and, eventually, status) arriving from the upstream,
+// including a chunked response split across two proxy_on_grpc_receive deliveries.
+type fakeGrpcCluster struct {
+	token uint32
+	cb    GrpcCalloutCallback
+
+	lastService  string
+	lastMethod   string
+	lastMetadata CallHeaders
+
+	sentMessages    [][]byte
+	sentEndOfStream []bool
+
+	canceledToken uint32
+	closedToken   uint32
+}
+
+func (c *fakeGrpcCluster) Open(cluster string, serviceName string, method string, initialMetadata CallHeaders, timeoutMillisecond uint32, cb GrpcCalloutCallback) (uint32, error) {
+	c.lastService = serviceName
+	c.lastMethod = method
+	c.lastMetadata = initialMetadata
+	c.cb = cb
+	c.token++
+	return c.token, nil
+}
+
+func (c *fakeGrpcCluster) Send(token uint32, message buffer.IoBuffer, endOfStream bool) error {
+	c.sentMessages = append(c.sentMessages, message.Bytes())
+	c.sentEndOfStream = append(c.sentEndOfStream, endOfStream)
+	return nil
+}
+
+func (c *fakeGrpcCluster) Cancel(token uint32) error {
+	c.canceledToken = token
+	return nil
+}
+
+func (c *fakeGrpcCluster) Close(token uint32) error {
+	c.closedToken = token
+	return nil
+}
+
+// deliver simulates the upstream delivering one message, invoking the callback Open was
+// given the same way a real GrpcCallout implementation would from its own goroutine.
+func (c *fakeGrpcCluster) deliver(data buffer.IoBuffer, trailers CallHeaders, grpcStatus int32) {
+	c.cb(c.token, data, trailers, grpcStatus)
+}
+
+func TestProxyGrpcStreamSendsMessagesAndDeliversChunkedResponse(t *testing.T) {
+	instance := newFakeInstance()
+	cluster := &fakeGrpcCluster{}
+
+	handler := &calloutTestHandler{
+		DefaultInstanceCallback: DefaultInstanceCallback{},
+		grpcCallout:             cluster,
+	}
+	instance.SetData(handler)
+
+	clusterAddr, clusterSize := instance.writeString(0, "fake_grpc_cluster")
+	serviceAddr, serviceSize := instance.writeString(64, "pkg.Service")
+	methodAddr, methodSize := instance.writeString(128, "Method")
+	metadataAddr, metadataSize := instance.writeHeaders(192, map[string]string{"x-req": "1"})
+
+	returnTokenAddr := int32(900)
+
+	result := proxyGrpcStream(instance, clusterAddr, clusterSize, serviceAddr, serviceSize, methodAddr, methodSize, metadataAddr, metadataSize, 1000, returnTokenAddr)
+	if result != WasmResultOk {
+		t.Fatalf("proxyGrpcStream: want WasmResultOk, got %d", result)
+	}
+
+	if cluster.lastService != "pkg.Service" || cluster.lastMethod != "Method" {
+		t.Errorf("cluster did not receive service/method: %q/%q", cluster.lastService, cluster.lastMethod)
+	}
+	if v, _ := cluster.lastMetadata.Get("x-req"); v != "1" {
+		t.Errorf("cluster did not receive initial metadata: %v", cluster.lastMetadata)
+	}
+
+	tokenBytes, err := instance.GetMemory(uint64(returnTokenAddr), 4)
+	if err != nil {
+		t.Fatalf("read back token: %v", err)
+	}
+	token := decodeUint32(tokenBytes)
+	if token == 0 {
+		t.Errorf("expected a non-zero token")
+	}
+
+	messageAddr, messageSize := instance.writeString(960, "request message")
+	if result := proxyGrpcSend(instance, int32(token), messageAddr, messageSize, 0); result != WasmResultOk {
+		t.Fatalf("proxyGrpcSend: want WasmResultOk, got %d", result)
+	}
+	if len(cluster.sentMessages) != 1 || string(cluster.sentMessages[0]) != "request message" {
+		t.Errorf("cluster did not receive the sent message: %+v", cluster.sentMessages)
+	}
+	if cluster.sentEndOfStream[0] {
+		t.Errorf("expected endOfStream=false for the first send")
+	}
+
+	// simulate the upstream response arriving in two chunks, the second carrying trailers
+	// and a final status, as a real gRPC stream would.
+	cluster.deliver(buffer.NewIoBufferString("resp-chunk-1"), nil, -1)
+	cluster.deliver(buffer.NewIoBufferString("resp-chunk-2"), CallHeaders{"x-trailer": "done"}, 0)
+
+	if len(instance.recorded) != 3 {
+		t.Fatalf("expected 2 proxy_on_grpc_receive calls and 1 proxy_on_grpc_close call, got %+v", instance.recorded)
+	}
+
+	for i, name := range []string{"proxy_on_grpc_receive", "proxy_on_grpc_receive", "proxy_on_grpc_close"} {
+		if instance.recorded[i].name != name {
+			t.Errorf("recorded[%d].name = %q, want %q", i, instance.recorded[i].name, name)
+		}
+		gotContextID, gotToken := instance.recorded[i].args[0], instance.recorded[i].args[1]
+		if gotContextID != handler.GetRootContextID() {
+			t.Errorf("recorded[%d] contextID = %d, want %d", i, gotContextID, handler.GetRootContextID())
+		}
+		if gotToken != int32(token) {
+			t.Errorf("recorded[%d] token = %d, want %d", i, gotToken, token)
+		}
+	}
+
+	if gotSize := instance.recorded[1].args[2]; gotSize != int32(len("resp-chunk-2")) {
+		t.Errorf("proxy_on_grpc_receive size = %d, want %d", gotSize, len("resp-chunk-2"))
+	}
+	if gotStatus := instance.recorded[2].args[2]; gotStatus != 0 {
+		t.Errorf("proxy_on_grpc_close grpcStatus = %d, want 0", gotStatus)
+	}
+
+	if result := proxyGrpcCancel(instance, int32(token)); result != WasmResultOk {
+		t.Fatalf("proxyGrpcCancel: want WasmResultOk, got %d", result)
+	}
+	if cluster.canceledToken != token {
+		t.Errorf("cluster.canceledToken = %d, want %d", cluster.canceledToken, token)
+	}
+
+	if result := proxyGrpcClose(instance, int32(token)); result != WasmResultOk {
+		t.Fatalf("proxyGrpcClose: want WasmResultOk, got %d", result)
+	}
+	if cluster.closedToken != token {
+		t.Errorf("cluster.closedToken = %d, want %d", cluster.closedToken, token)
+	}
+}
+
+// calloutTestHandler is a narrow ImportsHandler stub used only to exercise the callout host
+// functions in isolation from the rest of the ABI surface.
+type calloutTestHandler struct {
+	DefaultInstanceCallback
+	httpCallout HttpCallout
+	grpcCallout GrpcCallout
+}
+
+func (h *calloutTestHandler) GetHttpCallout() HttpCallout { return h.httpCallout }
+func (h *calloutTestHandler) GetGrpcCallout() GrpcCallout { return h.grpcCallout }
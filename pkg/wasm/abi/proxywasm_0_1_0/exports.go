@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxywasm_0_1_0
+
+import (
+	"mosn.io/mosn/pkg/types"
+)
+
+// exports adapts a types.WasmInstance's exported "proxy_on_*" functions to the Exports
+// interface. It is the counterpart of imports: where imports is called BY the wasm module,
+// exports is called INTO the wasm module.
+type exports struct {
+	instance types.WasmInstance
+}
+
+// NewExports builds the 0.1.0 Exports implementation for instance. Other ABI versions that
+// are supersets of 0.1.0 (e.g. proxywasm_0_2_0) embed it rather than reimplementing the
+// unchanged exports.
+func NewExports(instance types.WasmInstance) Exports {
+	return &exports{instance: instance}
+}
+
+func (e *exports) ProxyOnContextCreate(contextID int32, rootContextID int32) error {
+	_, err := e.instance.CallExportsFunc("proxy_on_context_create", contextID, rootContextID)
+	return err
+}
+
+func (e *exports) ProxyOnConfigure(rootContextID int32, confSize int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_configure", rootContextID, confSize)
+}
+
+func (e *exports) ProxyOnVmStart(rootContextID int32, vmConfigSize int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_vm_start", rootContextID, vmConfigSize)
+}
+
+func (e *exports) ProxyOnRequestHeaders(contextID int32, endOfStream int32, headerMapSize int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_request_headers", contextID, headerMapSize, endOfStream)
+}
+
+func (e *exports) ProxyOnRequestBody(contextID int32, bodyBufferLength int32, endOfStream int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_request_body", contextID, bodyBufferLength, endOfStream)
+}
+
+func (e *exports) ProxyOnResponseHeaders(contextID int32, endOfStream int32, headerMapSize int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_response_headers", contextID, headerMapSize, endOfStream)
+}
+
+func (e *exports) ProxyOnResponseBody(contextID int32, bodyBufferLength int32, endOfStream int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_response_body", contextID, bodyBufferLength, endOfStream)
+}
+
+func (e *exports) ProxyOnDone(contextID int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_done", contextID)
+}
+
+func (e *exports) ProxyOnDelete(contextID int32) error {
+	_, err := e.instance.CallExportsFunc("proxy_on_delete", contextID)
+	return err
+}
+
+func (e *exports) ProxyOnHttpCallResponse(contextID int32, token int32, numHeaders int32, bodySize int32, numTrailers int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_http_call_response", contextID, token, numHeaders, bodySize, numTrailers)
+}
+
+func (e *exports) ProxyOnGrpcReceive(contextID int32, token int32, responseSize int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_grpc_receive", contextID, token, responseSize)
+}
+
+func (e *exports) ProxyOnGrpcClose(contextID int32, token int32, grpcStatus int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_grpc_close", contextID, token, grpcStatus)
+}
+
+func (e *exports) ProxyOnQueueReady(rootContextID int32, queueID int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_queue_ready", rootContextID, queueID)
+}
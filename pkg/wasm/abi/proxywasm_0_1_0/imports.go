@@ -0,0 +1,262 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxywasm_0_1_0
+
+import (
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// Result codes returned to the wasm module by host (proxy_*) import functions, mirroring
+// proxy-wasm-cpp-host's WasmResult enum for ABI 0.1.0.
+const (
+	WasmResultOk              int32 = 0
+	WasmResultNotFound        int32 = 1
+	WasmResultBadArgument     int32 = 2
+	WasmResultCasMismatch     int32 = 3
+	WasmResultInternalFailure int32 = 9
+)
+
+// HostFunction is a host-side import implementation: it is invoked by the wasm engine with
+// the bound instance and the raw int32 arguments the module passed, and returns the single
+// int32 result the module expects back.
+type HostFunction func(instance types.WasmInstance, args ...int32) int32
+
+// HostFunctions returns the proxy_* host functions a 0.1.0 module may import, keyed by
+// their wasm import name. Engine packages (e.g. runtime/wasmer) call this once per module
+// instantiation to wire up the import object.
+func HostFunctions() map[string]HostFunction {
+	return map[string]HostFunction{
+		"proxy_log":                          proxyLog,
+		"proxy_get_header_map_value":         proxyGetHeaderMapValue,
+		"proxy_get_buffer_bytes":             proxyGetBufferBytes,
+		"proxy_set_tick_period_milliseconds": proxySetTickPeriodMilliseconds,
+		"proxy_http_call":                    proxyHttpCall,
+		"proxy_grpc_call":                    proxyGrpcCall,
+		"proxy_grpc_stream":                  proxyGrpcStream,
+		"proxy_grpc_send":                    proxyGrpcSend,
+		"proxy_grpc_cancel":                  proxyGrpcCancel,
+		"proxy_grpc_close":                   proxyGrpcClose,
+		"proxy_get_shared_data":              proxyGetSharedData,
+		"proxy_set_shared_data":              proxySetSharedData,
+		"proxy_register_shared_queue":        proxyRegisterSharedQueue,
+		"proxy_resolve_shared_queue":         proxyResolveSharedQueue,
+		"proxy_enqueue_shared_queue":         proxyEnqueueSharedQueue,
+		"proxy_dequeue_shared_queue":         proxyDequeueSharedQueue,
+	}
+}
+
+func handlerOf(instance types.WasmInstance) (ImportsHandler, bool) {
+	h, ok := instance.GetData().(ImportsHandler)
+	return h, ok
+}
+
+func proxyLog(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	logLevel, msgAddr, msgSize := args[0], args[1], args[2]
+	msg, err := instance.GetMemory(uint64(msgAddr), uint64(msgSize))
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	handler.Log(log.Level(logLevel), string(msg))
+	return WasmResultOk
+}
+
+func proxyGetHeaderMapValue(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	mapType, keyAddr, keySize, valueDataAddrAddr, valueSizeAddr := args[0], args[1], args[2], args[3], args[4]
+
+	key, err := instance.GetMemory(uint64(keyAddr), uint64(keySize))
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	header := headerMapOf(instance, handler, mapType)
+	if header == nil {
+		return WasmResultNotFound
+	}
+
+	value, ok := header.Get(string(key))
+	if !ok {
+		return WasmResultNotFound
+	}
+
+	return copyIntoInstance(instance, valueDataAddrAddr, valueSizeAddr, []byte(value))
+}
+
+func proxyGetBufferBytes(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	bufferType, start, maxSize, returnDataAddrAddr, returnSizeAddr := args[0], args[1], args[2], args[3], args[4]
+
+	buf := bufferOf(instance, handler, bufferType)
+	if buf == nil {
+		return WasmResultNotFound
+	}
+
+	bytes := buf.Bytes()
+	if int(start) > len(bytes) {
+		return WasmResultBadArgument
+	}
+
+	end := int(start) + int(maxSize)
+	if end > len(bytes) {
+		end = len(bytes)
+	}
+
+	return copyIntoInstance(instance, returnDataAddrAddr, returnSizeAddr, bytes[start:end])
+}
+
+func proxySetTickPeriodMilliseconds(instance types.WasmInstance, args ...int32) int32 {
+	// MOSN does not currently drive a periodic tick for wasm plugins; accept and ignore.
+	return WasmResultOk
+}
+
+// MapType selectors, mirroring proxy-wasm-cpp-host's MapType enum. 4-7 identify the
+// headers/trailers of the HTTP or gRPC callout response currently being delivered to the
+// module, i.e. only valid while inside ProxyOnHttpCallResponse/ProxyOnGrpcReceive.
+const (
+	MapTypeHttpRequestHeaders       int32 = 0
+	MapTypeHttpRequestTrailers      int32 = 1
+	MapTypeHttpResponseHeaders      int32 = 2
+	MapTypeHttpResponseTrailers     int32 = 3
+	MapTypeHttpCallResponseHeaders  int32 = 4
+	MapTypeHttpCallResponseTrailers int32 = 5
+	MapTypeGrpcReceiveTrailers      int32 = 6
+)
+
+// headerMapOf resolves a MapType selector to the matching header map, sourced either from
+// handler (request/response state owned by the invoking filter) or, for the callout
+// selectors, from the response currently active on instance's calloutState.
+func headerMapOf(instance types.WasmInstance, handler ImportsHandler, mapType int32) headerMap {
+	switch mapType {
+	case MapTypeHttpRequestHeaders:
+		return handler.GetHttpRequestHeader()
+	case MapTypeHttpRequestTrailers:
+		return handler.GetHttpRequestTrailer()
+	case MapTypeHttpResponseHeaders:
+		return handler.GetHttpResponseHeader()
+	case MapTypeHttpResponseTrailers:
+		return handler.GetHttpResponseTrailer()
+	case MapTypeHttpCallResponseHeaders:
+		if active := activeCallout(instance); active != nil {
+			return active.headers
+		}
+		return nil
+	case MapTypeHttpCallResponseTrailers, MapTypeGrpcReceiveTrailers:
+		if active := activeCallout(instance); active != nil {
+			return active.trailers
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// BufferType selectors, mirroring proxy-wasm-cpp-host's BufferType enum. 4 is the body of
+// the HTTP callout response, 5 the data of the gRPC message, both active only while inside
+// ProxyOnHttpCallResponse/ProxyOnGrpcReceive.
+const (
+	BufferTypeHttpRequestBody      int32 = 0
+	BufferTypeHttpResponseBody     int32 = 1
+	BufferTypeVmConfiguration      int32 = 2
+	BufferTypePluginConfiguration  int32 = 3
+	BufferTypeHttpCallResponseBody int32 = 4
+	BufferTypeGrpcReceiveBuffer    int32 = 5
+)
+
+// bufferOf resolves a BufferType selector to the matching buffer.
+func bufferOf(instance types.WasmInstance, handler ImportsHandler, bufferType int32) buffer.IoBuffer {
+	switch bufferType {
+	case BufferTypeHttpRequestBody:
+		return handler.GetHttpRequestBody()
+	case BufferTypeHttpResponseBody:
+		return handler.GetHttpResponseBody()
+	case BufferTypeVmConfiguration:
+		return handler.GetVmConfig()
+	case BufferTypePluginConfiguration:
+		return handler.GetPluginConfig()
+	case BufferTypeHttpCallResponseBody, BufferTypeGrpcReceiveBuffer:
+		if active := activeCallout(instance); active != nil {
+			return active.body
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// activeCallout returns the callout response currently being delivered to instance, if any.
+func activeCallout(instance types.WasmInstance) *calloutResponse {
+	v, ok := calloutStates.Load(instance)
+	if !ok {
+		return nil
+	}
+
+	s := v.(*calloutState)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// headerMap is the subset of api.HeaderMap the 0.1.0 imports need; kept narrow so tests can
+// satisfy it with a plain map-backed mock.
+type headerMap interface {
+	Get(key string) (string, bool)
+}
+
+// copyIntoInstance mallocs len(content) bytes inside instance, copies content into that
+// region, and writes the resulting address/size pair back through the two out-pointers the
+// wasm module passed, per the proxy-wasm calling convention for out-params.
+func copyIntoInstance(instance types.WasmInstance, addrOut int32, sizeOut int32, content []byte) int32 {
+	addr, err := instance.Malloc(int32(len(content)))
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	if err := instance.PutMemory(addr, content); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	if err := instance.PutMemory(uint64(addrOut), encodeUint32(uint32(addr))); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	if err := instance.PutMemory(uint64(sizeOut), encodeUint32(uint32(len(content)))); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+func encodeUint32(v uint32) []byte {
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)}
+}
@@ -0,0 +1,249 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxywasm_0_1_0
+
+import (
+	"errors"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// SharedDataStore is the host-side key/value store behind proxy_get_shared_data /
+// proxy_set_shared_data. It is shared by every instance running under the same vm_id, so a
+// producer instance and a consumer instance of one plugin can hand off state between them. A
+// production ImportsHandler backs this with a per-WasmPluginWrapper store; tests can fake it
+// directly.
+type SharedDataStore interface {
+	// Get returns the value currently stored under key for vmID, plus its cas, so the caller
+	// can round-trip it back through Set for compare-and-swap.
+	Get(vmID string, key string) (value []byte, cas uint32, ok bool)
+
+	// Set stores value under key for vmID. If cas is non-zero, the write only succeeds if it
+	// matches the entry's current cas (ErrCasMismatch otherwise, meaning another instance won
+	// the race); cas == 0 always overwrites unconditionally.
+	Set(vmID string, key string, value []byte, cas uint32) error
+}
+
+// ErrCasMismatch is returned by SharedDataStore.Set when cas no longer matches the stored
+// entry.
+var ErrCasMismatch = errors.New("proxywasm_0_1_0: shared data cas mismatch")
+
+// SharedQueue is the host-side message queue behind proxy_register_shared_queue,
+// proxy_resolve_shared_queue, proxy_enqueue_shared_queue, and proxy_dequeue_shared_queue.
+type SharedQueue interface {
+	// Register creates queueName under vmID if it does not exist yet, and marks instance's
+	// rootContextID as its consumer: a later Enqueue wakes it with
+	// Exports.ProxyOnQueueReady(rootContextID, queueID).
+	Register(vmID string, queueName string, instance types.WasmInstance, rootContextID int32) (queueID uint32, err error)
+
+	// Resolve looks up an existing queue by name without registering a consumer, e.g. for a
+	// producer that only wants to enqueue.
+	Resolve(vmID string, queueName string) (queueID uint32, ok bool)
+
+	// Enqueue appends data to queueID and wakes its consumer, if any, with
+	// ProxyOnQueueReady. producer is the instance making this call, so the implementation
+	// can tell whether it is also the queue's consumer: a caller only ever reaches Enqueue
+	// with producer's lock already held, and that same lock guards the consumer too when
+	// they're one and the same instance.
+	Enqueue(queueID uint32, data []byte, producer types.WasmInstance) error
+	Dequeue(queueID uint32) (data []byte, ok bool)
+}
+
+// ErrQueueNotFound is returned by SharedQueue.Enqueue when queueID is not a registered queue.
+var ErrQueueNotFound = errors.New("proxywasm_0_1_0: shared queue not found")
+
+func proxyGetSharedData(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	store := handler.GetSharedDataStore()
+	if store == nil {
+		return WasmResultNotFound
+	}
+
+	keyAddr, keySize, returnValueAddrAddr, returnValueSizeAddr, returnCasAddr := args[0], args[1], args[2], args[3], args[4]
+
+	key, err := readString(instance, keyAddr, keySize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	value, cas, found := store.Get(handler.GetVmID(), key)
+	if !found {
+		return WasmResultNotFound
+	}
+
+	if result := copyIntoInstance(instance, returnValueAddrAddr, returnValueSizeAddr, value); result != WasmResultOk {
+		return result
+	}
+
+	if err := instance.PutMemory(uint64(returnCasAddr), encodeUint32(cas)); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+func proxySetSharedData(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	store := handler.GetSharedDataStore()
+	if store == nil {
+		return WasmResultNotFound
+	}
+
+	keyAddr, keySize, valueAddr, valueSize, cas := args[0], args[1], args[2], args[3], args[4]
+
+	key, err := readString(instance, keyAddr, keySize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	value, err := instance.GetMemory(uint64(valueAddr), uint64(valueSize))
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	if err := store.Set(handler.GetVmID(), key, value, uint32(cas)); err != nil {
+		if errors.Is(err, ErrCasMismatch) {
+			return WasmResultCasMismatch
+		}
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+func proxyRegisterSharedQueue(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	queue := handler.GetSharedQueue()
+	if queue == nil {
+		return WasmResultNotFound
+	}
+
+	nameAddr, nameSize, returnQueueIDAddr := args[0], args[1], args[2]
+
+	name, err := readString(instance, nameAddr, nameSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	queueID, err := queue.Register(handler.GetVmID(), name, instance, handler.GetRootContextID())
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	if err := instance.PutMemory(uint64(returnQueueIDAddr), encodeUint32(queueID)); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+func proxyResolveSharedQueue(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	queue := handler.GetSharedQueue()
+	if queue == nil {
+		return WasmResultNotFound
+	}
+
+	vmIDAddr, vmIDSize, nameAddr, nameSize, returnQueueIDAddr := args[0], args[1], args[2], args[3], args[4]
+
+	vmID, err := readString(instance, vmIDAddr, vmIDSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	name, err := readString(instance, nameAddr, nameSize)
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	queueID, ok := queue.Resolve(vmID, name)
+	if !ok {
+		return WasmResultNotFound
+	}
+
+	if err := instance.PutMemory(uint64(returnQueueIDAddr), encodeUint32(queueID)); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+func proxyEnqueueSharedQueue(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	queue := handler.GetSharedQueue()
+	if queue == nil {
+		return WasmResultNotFound
+	}
+
+	queueID, valueAddr, valueSize := uint32(args[0]), args[1], args[2]
+
+	value, err := instance.GetMemory(uint64(valueAddr), uint64(valueSize))
+	if err != nil {
+		return WasmResultBadArgument
+	}
+
+	if err := queue.Enqueue(queueID, value, instance); err != nil {
+		if errors.Is(err, ErrQueueNotFound) {
+			return WasmResultNotFound
+		}
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+func proxyDequeueSharedQueue(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := handlerOf(instance)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	queue := handler.GetSharedQueue()
+	if queue == nil {
+		return WasmResultNotFound
+	}
+
+	queueID, returnValueAddrAddr, returnValueSizeAddr := uint32(args[0]), args[1], args[2]
+
+	value, ok := queue.Dequeue(queueID)
+	if !ok {
+		return WasmResultNotFound
+	}
+
+	return copyIntoInstance(instance, returnValueAddrAddr, returnValueSizeAddr, value)
+}
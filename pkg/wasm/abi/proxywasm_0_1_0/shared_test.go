@@ -0,0 +1,265 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxywasm_0_1_0
+
+import (
+	"sync"
+	"testing"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// fakeSharedDataStore is a minimal SharedDataStore used to exercise the proxy_get_shared_data
+// / proxy_set_shared_data host functions without the real per-WasmPluginWrapper store.
+type fakeSharedDataStore struct {
+	mu      sync.Mutex
+	entries map[string]fakeSharedEntry
+}
+
+type fakeSharedEntry struct {
+	value []byte
+	cas   uint32
+}
+
+func newFakeSharedDataStore() *fakeSharedDataStore {
+	return &fakeSharedDataStore{entries: make(map[string]fakeSharedEntry)}
+}
+
+func (s *fakeSharedDataStore) Get(vmID string, key string) ([]byte, uint32, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[vmID+"/"+key]
+	if !ok {
+		return nil, 0, false
+	}
+	return e.value, e.cas, true
+}
+
+func (s *fakeSharedDataStore) Set(vmID string, key string, value []byte, cas uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := vmID + "/" + key
+	e, ok := s.entries[k]
+	if ok && cas != 0 && cas != e.cas {
+		return ErrCasMismatch
+	}
+
+	s.entries[k] = fakeSharedEntry{value: append([]byte(nil), value...), cas: e.cas + 1}
+	return nil
+}
+
+// fakeSharedQueue is a minimal SharedQueue: a producer resolves a queue registered by a
+// consumer, and enqueuing re-enters the consumer's recorded instance directly, mirroring how
+// the production store calls back into CallExportsFunc.
+type fakeSharedQueue struct {
+	mu       sync.Mutex
+	byName   map[string]uint32
+	data     map[uint32][][]byte
+	consumer map[uint32]*fakeQueueConsumer
+	nextID   uint32
+}
+
+type fakeQueueConsumer struct {
+	instance      types.WasmInstance
+	rootContextID int32
+}
+
+func newFakeSharedQueue() *fakeSharedQueue {
+	return &fakeSharedQueue{
+		byName:   make(map[string]uint32),
+		data:     make(map[uint32][][]byte),
+		consumer: make(map[uint32]*fakeQueueConsumer),
+	}
+}
+
+func (q *fakeSharedQueue) Register(vmID string, queueName string, instance types.WasmInstance, rootContextID int32) (uint32, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := vmID + "/" + queueName
+	id, ok := q.byName[key]
+	if !ok {
+		q.nextID++
+		id = q.nextID
+		q.byName[key] = id
+	}
+	q.consumer[id] = &fakeQueueConsumer{instance: instance, rootContextID: rootContextID}
+	return id, nil
+}
+
+func (q *fakeSharedQueue) Resolve(vmID string, queueName string) (uint32, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	id, ok := q.byName[vmID+"/"+queueName]
+	return id, ok
+}
+
+func (q *fakeSharedQueue) Enqueue(queueID uint32, data []byte, producer types.WasmInstance) error {
+	q.mu.Lock()
+	q.data[queueID] = append(q.data[queueID], append([]byte(nil), data...))
+	consumer := q.consumer[queueID]
+	q.mu.Unlock()
+
+	if consumer != nil {
+		_, _ = consumer.instance.CallExportsFunc("proxy_on_queue_ready", consumer.rootContextID, int32(queueID))
+	}
+	return nil
+}
+
+func (q *fakeSharedQueue) Dequeue(queueID uint32) ([]byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := q.data[queueID]
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	data := items[0]
+	q.data[queueID] = items[1:]
+	return data, true
+}
+
+// sharedTestHandler is a narrow ImportsHandler stub exercising only the shared-data/
+// shared-queue accessors.
+type sharedTestHandler struct {
+	DefaultInstanceCallback
+	vmID          string
+	rootContextID int32
+	store         SharedDataStore
+	queue         SharedQueue
+}
+
+func (h *sharedTestHandler) GetVmID() string                      { return h.vmID }
+func (h *sharedTestHandler) GetRootContextID() int32              { return h.rootContextID }
+func (h *sharedTestHandler) GetSharedDataStore() SharedDataStore { return h.store }
+func (h *sharedTestHandler) GetSharedQueue() SharedQueue          { return h.queue }
+
+func TestProxySharedDataSetGetRoundTripsAndEnforcesCas(t *testing.T) {
+	instance := newFakeInstance()
+	handler := &sharedTestHandler{vmID: "vm1", store: newFakeSharedDataStore()}
+	instance.SetData(handler)
+
+	keyAddr, keySize := instance.writeString(0, "counter")
+	valueAddr, valueSize := instance.writeString(64, "1")
+
+	returnValueAddrAddr, returnValueSizeAddr, returnCasAddr := int32(900), int32(904), int32(908)
+
+	if result := proxySetSharedData(instance, keyAddr, keySize, valueAddr, valueSize, 0); result != WasmResultOk {
+		t.Fatalf("proxySetSharedData (initial): want WasmResultOk, got %d", result)
+	}
+
+	if result := proxyGetSharedData(instance, keyAddr, keySize, returnValueAddrAddr, returnValueSizeAddr, returnCasAddr); result != WasmResultOk {
+		t.Fatalf("proxyGetSharedData: want WasmResultOk, got %d", result)
+	}
+
+	valAddrBytes, _ := instance.GetMemory(uint64(returnValueAddrAddr), 4)
+	valSizeBytes, _ := instance.GetMemory(uint64(returnValueSizeAddr), 4)
+	casBytes, _ := instance.GetMemory(uint64(returnCasAddr), 4)
+
+	valAddr, valSize, cas := decodeUint32(valAddrBytes), decodeUint32(valSizeBytes), decodeUint32(casBytes)
+
+	got, _ := instance.GetMemory(uint64(valAddr), uint64(valSize))
+	if string(got) != "1" {
+		t.Errorf("proxyGetSharedData value = %q, want %q", got, "1")
+	}
+	if cas == 0 {
+		t.Errorf("expected a non-zero cas after the first set")
+	}
+
+	valueAddr2, valueSize2 := instance.writeString(128, "2")
+
+	if result := proxySetSharedData(instance, keyAddr, keySize, valueAddr2, valueSize2, int32(cas+100)); result != WasmResultCasMismatch {
+		t.Errorf("proxySetSharedData with stale cas: want WasmResultCasMismatch, got %d", result)
+	}
+
+	if result := proxySetSharedData(instance, keyAddr, keySize, valueAddr2, valueSize2, int32(cas)); result != WasmResultOk {
+		t.Errorf("proxySetSharedData with current cas: want WasmResultOk, got %d", result)
+	}
+}
+
+func TestSharedQueueProducerEnqueueWakesConsumer(t *testing.T) {
+	queue := newFakeSharedQueue()
+
+	consumerInstance := newFakeInstance()
+	consumerHandler := &sharedTestHandler{vmID: "vm1", rootContextID: 7, queue: queue}
+	consumerInstance.SetData(consumerHandler)
+
+	producerInstance := newFakeInstance()
+	producerHandler := &sharedTestHandler{vmID: "vm1", rootContextID: 5, queue: queue}
+	producerInstance.SetData(producerHandler)
+
+	nameAddr, nameSize := consumerInstance.writeString(0, "work_queue")
+	returnQueueIDAddr := int32(900)
+
+	if result := proxyRegisterSharedQueue(consumerInstance, nameAddr, nameSize, returnQueueIDAddr); result != WasmResultOk {
+		t.Fatalf("proxyRegisterSharedQueue: want WasmResultOk, got %d", result)
+	}
+	queueIDBytes, _ := consumerInstance.GetMemory(uint64(returnQueueIDAddr), 4)
+	queueID := decodeUint32(queueIDBytes)
+
+	vmIDAddr, vmIDSize := producerInstance.writeString(0, "vm1")
+	pNameAddr, pNameSize := producerInstance.writeString(64, "work_queue")
+	pReturnQueueIDAddr := int32(900)
+
+	if result := proxyResolveSharedQueue(producerInstance, vmIDAddr, vmIDSize, pNameAddr, pNameSize, pReturnQueueIDAddr); result != WasmResultOk {
+		t.Fatalf("proxyResolveSharedQueue: want WasmResultOk, got %d", result)
+	}
+	resolvedBytes, _ := producerInstance.GetMemory(uint64(pReturnQueueIDAddr), 4)
+	if resolved := decodeUint32(resolvedBytes); resolved != queueID {
+		t.Fatalf("proxyResolveSharedQueue queueID = %d, want %d", resolved, queueID)
+	}
+
+	jobAddr, jobSize := producerInstance.writeString(128, "job-1")
+	if result := proxyEnqueueSharedQueue(producerInstance, int32(queueID), jobAddr, jobSize); result != WasmResultOk {
+		t.Fatalf("proxyEnqueueSharedQueue: want WasmResultOk, got %d", result)
+	}
+
+	if len(consumerInstance.recorded) != 1 || consumerInstance.recorded[0].name != "proxy_on_queue_ready" {
+		t.Fatalf("expected consumer to be woken via a single proxy_on_queue_ready call, got %+v", consumerInstance.recorded)
+	}
+
+	woke := consumerInstance.recorded[0]
+	if woke.args[0] != consumerHandler.rootContextID {
+		t.Errorf("proxy_on_queue_ready rootContextID = %d, want %d", woke.args[0], consumerHandler.rootContextID)
+	}
+	if woke.args[1] != int32(queueID) {
+		t.Errorf("proxy_on_queue_ready queueID = %d, want %d", woke.args[1], queueID)
+	}
+
+	dequeueAddrAddr, dequeueSizeAddr := int32(950), int32(954)
+	if result := proxyDequeueSharedQueue(consumerInstance, int32(queueID), dequeueAddrAddr, dequeueSizeAddr); result != WasmResultOk {
+		t.Fatalf("proxyDequeueSharedQueue: want WasmResultOk, got %d", result)
+	}
+
+	addrBytes, _ := consumerInstance.GetMemory(uint64(dequeueAddrAddr), 4)
+	sizeBytes, _ := consumerInstance.GetMemory(uint64(dequeueSizeAddr), 4)
+	addr, size := decodeUint32(addrBytes), decodeUint32(sizeBytes)
+
+	job, _ := consumerInstance.GetMemory(uint64(addr), uint64(size))
+	if string(job) != "job-1" {
+		t.Errorf("dequeued job = %q, want %q", job, "job-1")
+	}
+
+	if result := proxyDequeueSharedQueue(consumerInstance, int32(queueID), dequeueAddrAddr, dequeueSizeAddr); result != WasmResultNotFound {
+		t.Errorf("proxyDequeueSharedQueue on an empty queue: want WasmResultNotFound, got %d", result)
+	}
+}
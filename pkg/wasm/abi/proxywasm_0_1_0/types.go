@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package proxywasm_0_1_0 implements the proxy-wasm ABI as defined by proxy-wasm-cpp-host /
+// proxy-wasm-rust-sdk at ABI version 0.1.0. It is registered under ProxyWasmABI_0_1_0 and is
+// the ABI most existing SDKs (envoy-proxy's early Rust/Go/TinyGo SDKs) were built against.
+package proxywasm_0_1_0
+
+import (
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/buffer"
+)
+
+// ProxyWasmABI_0_1_0 is the ABI version string used both as the wasm module's exported
+// symbol prefix and as the WasmVmConfig.AbiVersion / abi.GetABI lookup key.
+const ProxyWasmABI_0_1_0 = "proxy_abi_version_0_1_0"
+
+// ImportsHandler is the host-side implementation of the functions a 0.1.0 wasm module
+// imports. MOSN's http/stream filters implement this to expose request/response state to
+// the plugin; tests implement it with a mock.
+type ImportsHandler interface {
+	GetRootContextID() int32
+
+	GetVmConfig() buffer.IoBuffer
+	GetPluginConfig() buffer.IoBuffer
+
+	GetHttpRequestHeader() api.HeaderMap
+	GetHttpRequestBody() buffer.IoBuffer
+	GetHttpRequestTrailer() api.HeaderMap
+
+	GetHttpResponseHeader() api.HeaderMap
+	GetHttpResponseBody() buffer.IoBuffer
+	GetHttpResponseTrailer() api.HeaderMap
+
+	// GetVmID returns the vm_id configured for this plugin's VM. Shared data and shared
+	// queues are scoped by this value, so every instance of a vm_id shares one namespace.
+	GetVmID() string
+
+	// GetSharedDataStore returns the key/value store backing proxy_get_shared_data /
+	// proxy_set_shared_data, or nil if this context does not allow shared data.
+	GetSharedDataStore() SharedDataStore
+
+	// GetSharedQueue returns the message queue backing proxy_register_shared_queue and its
+	// companion imports, or nil if this context does not allow shared queues.
+	GetSharedQueue() SharedQueue
+
+	// GetHttpCallout returns the dispatcher a proxy_http_call import sends outgoing HTTP
+	// callouts through, or nil if this context does not allow them.
+	GetHttpCallout() HttpCallout
+
+	// GetGrpcCallout returns the dispatcher the proxy_grpc_* imports send outgoing gRPC
+	// callouts through, or nil if this context does not allow them.
+	GetGrpcCallout() GrpcCallout
+
+	Log(level log.Level, msg string)
+}
+
+// DefaultInstanceCallback provides no-op implementations of ImportsHandler so that callers
+// only need to override the methods relevant to the scenario under test.
+type DefaultInstanceCallback struct{}
+
+func (d *DefaultInstanceCallback) GetRootContextID() int32 { return 0 }
+
+func (d *DefaultInstanceCallback) GetVmConfig() buffer.IoBuffer { return nil }
+
+func (d *DefaultInstanceCallback) GetPluginConfig() buffer.IoBuffer { return nil }
+
+func (d *DefaultInstanceCallback) GetHttpRequestHeader() api.HeaderMap { return nil }
+
+func (d *DefaultInstanceCallback) GetHttpRequestBody() buffer.IoBuffer { return nil }
+
+func (d *DefaultInstanceCallback) GetHttpRequestTrailer() api.HeaderMap { return nil }
+
+func (d *DefaultInstanceCallback) GetHttpResponseHeader() api.HeaderMap { return nil }
+
+func (d *DefaultInstanceCallback) GetHttpResponseBody() buffer.IoBuffer { return nil }
+
+func (d *DefaultInstanceCallback) GetHttpResponseTrailer() api.HeaderMap { return nil }
+
+func (d *DefaultInstanceCallback) GetVmID() string { return "" }
+
+func (d *DefaultInstanceCallback) GetSharedDataStore() SharedDataStore { return nil }
+
+func (d *DefaultInstanceCallback) GetSharedQueue() SharedQueue { return nil }
+
+func (d *DefaultInstanceCallback) GetHttpCallout() HttpCallout { return nil }
+
+func (d *DefaultInstanceCallback) GetGrpcCallout() GrpcCallout { return nil }
+
+func (d *DefaultInstanceCallback) Log(level log.Level, msg string) {}
+
+// Exports is the set of functions the host calls into the wasm module, i.e. the module's
+// "proxy_on_*" exports.
+type Exports interface {
+	ProxyOnContextCreate(contextID int32, rootContextID int32) error
+
+	ProxyOnConfigure(rootContextID int32, confSize int32) (int32, error)
+	ProxyOnVmStart(rootContextID int32, vmConfigSize int32) (int32, error)
+
+	ProxyOnRequestHeaders(contextID int32, endOfStream int32, headerMapSize int32) (int32, error)
+	ProxyOnRequestBody(contextID int32, bodyBufferLength int32, endOfStream int32) (int32, error)
+	ProxyOnResponseHeaders(contextID int32, endOfStream int32, headerMapSize int32) (int32, error)
+	ProxyOnResponseBody(contextID int32, bodyBufferLength int32, endOfStream int32) (int32, error)
+
+	ProxyOnDone(contextID int32) (int32, error)
+	ProxyOnDelete(contextID int32) error
+
+	// ProxyOnHttpCallResponse re-enters the module with the result of a proxy_http_call
+	// issued earlier by contextID; the module reads the response via proxy_get_map_value /
+	// proxy_get_buffer_bytes against the HttpCallResponse* selectors while inside this call.
+	ProxyOnHttpCallResponse(contextID int32, token int32, numHeaders int32, bodySize int32, numTrailers int32) (int32, error)
+
+	// ProxyOnGrpcReceive delivers one message received on an open proxy_grpc_call/
+	// proxy_grpc_stream identified by token.
+	ProxyOnGrpcReceive(contextID int32, token int32, responseSize int32) (int32, error)
+
+	// ProxyOnGrpcClose reports the final status of a gRPC callout identified by token.
+	ProxyOnGrpcClose(contextID int32, token int32, grpcStatus int32) (int32, error)
+
+	// ProxyOnQueueReady re-enters the module on rootContextID because data was enqueued onto
+	// queueID by a proxy_enqueue_shared_queue call from another instance sharing this vm_id.
+	ProxyOnQueueReady(rootContextID int32, queueID int32) (int32, error)
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxywasm_0_2_0
+
+import (
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/wasm/abi"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+)
+
+func init() {
+	abi.RegisterABI(ProxyWasmABI_0_2_0, NewABI)
+}
+
+type wasmABI struct {
+	instance types.WasmInstance
+	imports  interface{}
+	exports  *exports
+}
+
+// NewABI constructs a 0.2.0 ABI bound to instance.
+func NewABI(instance types.WasmInstance) types.ABI {
+	return &wasmABI{
+		instance: instance,
+		exports: &exports{
+			Exports:  proxywasm_0_1_0.NewExports(instance),
+			instance: instance,
+		},
+	}
+}
+
+func (a *wasmABI) Name() string {
+	return ProxyWasmABI_0_2_0
+}
+
+func (a *wasmABI) SetImports(imports interface{}) {
+	a.imports = imports
+	a.instance.SetData(imports)
+}
+
+func (a *wasmABI) GetImports() interface{} {
+	return a.imports
+}
+
+func (a *wasmABI) SetInstance(instance types.WasmInstance) {
+	a.instance = instance
+	a.exports.instance = instance
+	a.exports.Exports = proxywasm_0_1_0.NewExports(instance)
+}
+
+func (a *wasmABI) GetInstance() types.WasmInstance {
+	return a.instance
+}
+
+func (a *wasmABI) GetExports() interface{} {
+	return Exports(a.exports)
+}
@@ -0,0 +1,34 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxywasm_0_2_0
+
+import (
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+)
+
+// exports adds the 0.2.0 exports on top of the shared 0.1.0 implementation, which already
+// covers every export unchanged between the two versions.
+type exports struct {
+	proxywasm_0_1_0.Exports
+	instance types.WasmInstance
+}
+
+func (e *exports) ProxyOnForeignFunction(rootContextID int32, functionID int32, dataSize int32) (int32, error) {
+	return e.instance.CallExportsFunc("proxy_on_foreign_function", rootContextID, functionID, dataSize)
+}
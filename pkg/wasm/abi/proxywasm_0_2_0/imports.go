@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxywasm_0_2_0
+
+import (
+	"errors"
+
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+)
+
+// Result codes, extending proxywasm_0_1_0's WasmResult* constants with the values added in
+// ABI 0.2.0.
+const (
+	WasmResultOk              = proxywasm_0_1_0.WasmResultOk
+	WasmResultNotFound        = proxywasm_0_1_0.WasmResultNotFound
+	WasmResultBadArgument     = proxywasm_0_1_0.WasmResultBadArgument
+	WasmResultInternalFailure = proxywasm_0_1_0.WasmResultInternalFailure
+
+	// WasmResultUnimplemented is returned for imports a host recognizes but does not
+	// implement, distinguishing "no such import" from "not supported here".
+	WasmResultUnimplemented int32 = 12
+)
+
+// ErrForeignFunctionNotFound is returned by ImportsHandler.CallForeignFunction when
+// funcName has no registered implementation.
+var ErrForeignFunctionNotFound = errors.New("proxywasm_0_2_0: foreign function not found")
+
+// BufferType additions over 0.1.0: the body of the most recently completed
+// proxy_http_call/proxy_grpc_call callout, readable from ProxyOnHttpCallResponse /
+// ProxyOnGrpcReceive via proxy_get_buffer_bytes(HttpCallResponseBody, ...).
+const (
+	BufferTypeHttpRequestBody      int32 = 0
+	BufferTypeHttpResponseBody     int32 = 1
+	BufferTypeVmConfiguration      int32 = 2
+	BufferTypePluginConfiguration  int32 = 3
+	BufferTypeHttpCallResponseBody int32 = 4
+	BufferTypeGrpcReceiveBuffer    int32 = 5
+)
+
+// HostFunctions returns the proxy_* host functions a 0.2.0 module may import. It starts
+// from the 0.1.0 table and applies the 0.2.0 rename/additions on top, so new host
+// functionality doesn't have to be duplicated between the two ABI versions.
+func HostFunctions() map[string]proxywasm_0_1_0.HostFunction {
+	fns := proxywasm_0_1_0.HostFunctions()
+
+	// proxy_get_header_map_value was renamed to proxy_get_map_value in 0.2.0; the
+	// underlying behavior (and headerMap selector values) is unchanged.
+	fns["proxy_get_map_value"] = fns["proxy_get_header_map_value"]
+	delete(fns, "proxy_get_header_map_value")
+
+	fns["proxy_call_foreign_function"] = proxyCallForeignFunction
+	fns["proxy_set_effective_context"] = proxySetEffectiveContext
+
+	return fns
+}
+
+func proxyCallForeignFunction(instance types.WasmInstance, args ...int32) int32 {
+	handler, ok := instance.GetData().(ImportsHandler)
+	if !ok {
+		return WasmResultInternalFailure
+	}
+
+	funcNameAddr, funcNameSize, argAddr, argSize, returnDataAddrAddr, returnSizeAddr := args[0], args[1], args[2], args[3], args[4], args[5]
+
+	funcName, err := instance.GetMemory(uint64(funcNameAddr), uint64(funcNameSize))
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	arg, err := instance.GetMemory(uint64(argAddr), uint64(argSize))
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	result, err := handler.CallForeignFunction(string(funcName), arg)
+	if err == ErrForeignFunctionNotFound {
+		return WasmResultNotFound
+	}
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	return copyIntoInstance(instance, returnDataAddrAddr, returnSizeAddr, result)
+}
+
+// proxySetEffectiveContext switches the context subsequent imports in this call act on to
+// contextID, without that context having to be the one the module is currently executing
+// proxy_on_* for. This lets a plugin, e.g., read headers belonging to a different
+// in-flight stream while processing an async callout response.
+func proxySetEffectiveContext(instance types.WasmInstance, args ...int32) int32 {
+	// MOSN binds exactly one stream's state to an instance invocation at a time; accept the
+	// switch when it targets the currently bound context and reject cross-context switches.
+	contextID := args[0]
+	if current, ok := instance.GetData().(interface{ GetRootContextID() int32 }); ok && current.GetRootContextID() == contextID {
+		return WasmResultOk
+	}
+	return WasmResultUnimplemented
+}
+
+// copyIntoInstance is shared with the 0.1.0 import implementations' out-param convention;
+// it's re-declared here rather than exported from proxywasm_0_1_0 because it operates on
+// the instance, not on ABI-specific state.
+func copyIntoInstance(instance types.WasmInstance, addrOut int32, sizeOut int32, content []byte) int32 {
+	addr, err := instance.Malloc(int32(len(content)))
+	if err != nil {
+		return WasmResultInternalFailure
+	}
+
+	if err := instance.PutMemory(addr, content); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	if err := instance.PutMemory(uint64(addrOut), encodeUint32(uint32(addr))); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	if err := instance.PutMemory(uint64(sizeOut), encodeUint32(uint32(len(content)))); err != nil {
+		return WasmResultInternalFailure
+	}
+
+	return WasmResultOk
+}
+
+func encodeUint32(v uint32) []byte {
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)}
+}
@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package proxywasm_0_2_0 implements the proxy-wasm ABI at version 0.2.0, as used by newer
+// proxy-wasm-rust-sdk / proxy-wasm-go-sdk / TinyGo builds. It is a superset of 0.1.0:
+// proxy_get_header_map_value was renamed to proxy_get_map_value, foreign function calls and
+// an effective-context switch were added, and new result codes were introduced. Plugins
+// select it by setting WasmVmConfig.AbiVersion to ProxyWasmABI_0_2_0.
+package proxywasm_0_2_0
+
+import (
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+)
+
+// ProxyWasmABI_0_2_0 is the ABI version string used for WasmVmConfig.AbiVersion / abi.GetABI.
+const ProxyWasmABI_0_2_0 = "proxy_abi_version_0_2_0"
+
+// ImportsHandler extends the 0.1.0 handler with the host-side hooks 0.2.0 modules may use:
+// foreign function calls and reading the body of an outstanding proxy_http_call/proxy_grpc_call.
+type ImportsHandler interface {
+	proxywasm_0_1_0.ImportsHandler
+
+	// CallForeignFunction dispatches a named host-registered foreign function, as used by
+	// SDK helpers that don't map to a dedicated proxy_* import (e.g. compression, hashing).
+	// It returns (nil, ErrForeignFunctionNotFound) if funcName isn't registered.
+	CallForeignFunction(funcName string, arg []byte) ([]byte, error)
+}
+
+// DefaultInstanceCallback provides no-op 0.2.0 additions on top of the 0.1.0 defaults, so
+// callers only need to override what their scenario exercises.
+type DefaultInstanceCallback struct {
+	proxywasm_0_1_0.DefaultInstanceCallback
+}
+
+func (d *DefaultInstanceCallback) CallForeignFunction(funcName string, arg []byte) ([]byte, error) {
+	return nil, ErrForeignFunctionNotFound
+}
+
+// Exports is the 0.2.0 module-side export surface: 0.1.0's Exports plus ProxyOnForeignFunction.
+type Exports interface {
+	proxywasm_0_1_0.Exports
+
+	ProxyOnForeignFunction(rootContextID int32, functionID int32, dataSize int32) (int32, error)
+}
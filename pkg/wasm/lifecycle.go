@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"time"
+
+	"mosn.io/mosn/pkg/log"
+)
+
+// PluginState is a plugin generation's position in WasmPluginWrapper's reload lifecycle.
+type PluginState int32
+
+const (
+	// PluginStateLoading is set while the module is compiling and its
+	// ProxyOnConfigure/ProxyOnVmStart are warming up, before AddOrUpdateWasm switches traffic
+	// to it.
+	PluginStateLoading PluginState = iota
+	// PluginStateReady is set once a generation is serving traffic as WasmPluginWrapper's
+	// current plugin.
+	PluginStateReady
+	// PluginStateDraining is set on a generation once a newer one has taken over traffic; its
+	// instances are kept alive only until every context they still have outstanding finishes.
+	PluginStateDraining
+	// PluginStateStopped is set once a generation's instances have all been released, whether
+	// because every outstanding context finished or because the drain timeout forced it.
+	PluginStateStopped
+)
+
+// String returns the state's name, e.g. "Draining".
+func (s PluginState) String() string {
+	switch s {
+	case PluginStateLoading:
+		return "Loading"
+	case PluginStateReady:
+		return "Ready"
+	case PluginStateDraining:
+		return "Draining"
+	case PluginStateStopped:
+		return "Stopped"
+	default:
+		return "Unknown"
+	}
+}
+
+// PluginHandler is implemented by filters or other components that want to observe a
+// WasmPluginWrapper's lifecycle, e.g. to stop routing new requests to a plugin the moment it
+// enters PluginStateDraining. Register one via WasmPluginWrapper.RegisterPluginHandler.
+type PluginHandler interface {
+	OnPluginStateChanged(pluginName string, state PluginState)
+}
+
+const (
+	// defaultDrainTimeout applies when a WasmPluginConfig doesn't set DrainTimeoutMs.
+	defaultDrainTimeout = 10 * time.Second
+	drainPollInterval   = 50 * time.Millisecond
+)
+
+// pluginGeneration pairs one compiled/instantiated WasmPlugin with its lifecycle state. A
+// WasmPluginWrapper holds up to two at once: current (Loading then Ready) and, while a reload
+// is draining the plugin it replaced, previous (Draining then Stopped).
+type pluginGeneration struct {
+	plugin WasmPlugin
+	state  PluginState
+}
+
+// RegisterPluginHandler adds h to the set notified of this wrapper's lifecycle transitions.
+func (w *WasmPluginWrapper) RegisterPluginHandler(h PluginHandler) {
+	w.handlersMux.Lock()
+	defer w.handlersMux.Unlock()
+
+	w.handlers = append(w.handlers, h)
+}
+
+func (w *WasmPluginWrapper) notify(state PluginState) {
+	w.handlersMux.RLock()
+	handlers := append([]PluginHandler(nil), w.handlers...)
+	w.handlersMux.RUnlock()
+
+	name := w.GetConfig().PluginName
+	for _, h := range handlers {
+		h.OnPluginStateChanged(name, state)
+	}
+}
+
+// State returns the lifecycle state of the generation currently serving traffic, or
+// PluginStateStopped if none has loaded successfully yet.
+func (w *WasmPluginWrapper) State() PluginState {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	if w.current == nil {
+		return PluginStateStopped
+	}
+	return w.current.state
+}
+
+// startDraining demotes previous to PluginStateDraining and spins a goroutine that releases it
+// - via PluginStateStopped - once ActiveContexts reaches zero or drainTimeout elapses,
+// whichever comes first. This is only as graceful as ActiveContexts is accurate: see the
+// WasmPlugin.ActiveContexts doc for why it currently always reads 0 in this tree.
+func (w *WasmPluginWrapper) startDraining(previous *pluginGeneration) {
+	previous.state = PluginStateDraining
+
+	w.mux.Lock()
+	w.previous = previous
+	timeout := w.drainTimeout
+	w.mux.Unlock()
+
+	w.notify(PluginStateDraining)
+
+	go func() {
+		deadline := time.NewTimer(timeout)
+		defer deadline.Stop()
+		ticker := time.NewTicker(drainPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if previous.plugin.ActiveContexts() <= 0 {
+					w.finishDraining(previous, false)
+					return
+				}
+			case <-deadline.C:
+				w.finishDraining(previous, true)
+				return
+			}
+		}
+	}()
+}
+
+func (w *WasmPluginWrapper) finishDraining(previous *pluginGeneration, forced bool) {
+	if forced {
+		log.DefaultLogger.Warnf("[wasm] plugin %s: drain timeout with %d context(s) still outstanding, forcing release",
+			w.GetConfig().PluginName, previous.plugin.ActiveContexts())
+	}
+
+	previous.plugin.Close()
+	previous.state = PluginStateStopped
+
+	w.mux.Lock()
+	if w.previous == previous {
+		w.previous = nil
+	}
+	w.mux.Unlock()
+
+	w.notify(PluginStateStopped)
+}
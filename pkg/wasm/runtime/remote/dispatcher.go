@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// dispatcher multiplexes synchronous calls and one-way events over a single frameStream,
+// correlating each KindExportCall/KindImportCall with its KindResponse by CallID. Both the
+// MOSN-side module (outbound ExportCall, inbound ImportCall) and the sidecar (outbound
+// ImportCall, inbound ExportCall) use the same dispatcher; which direction a given Method name
+// means is purely up to the handle callback each side installs. This is the same
+// token-correlation shape pkg/wasm/abi/proxywasm_0_1_0's calloutState uses for HTTP/gRPC
+// callouts, just generalized to every call crossing the process boundary.
+type dispatcher struct {
+	stream frameStream
+	nextID uint64
+
+	// sendMu serializes every Send on stream: grpc.ClientStream/ServerStream forbid concurrent
+	// SendMsg calls from multiple goroutines, and this dispatcher is shared by every instance
+	// pooled off the same module, so call/respond/fail/event can race each other freely
+	// without it.
+	sendMu sync.Mutex
+
+	mu       sync.Mutex
+	pending  map[uint64]chan *Frame
+	closed   bool
+	closedCh chan struct{}
+	closeErr error
+
+	// handle processes an incoming frame that isn't a KindResponse, i.e. a call or event the
+	// peer initiated. It must reply via respond for KindExportCall/KindImportCall frames.
+	handle func(f *Frame)
+}
+
+func newDispatcher(stream frameStream, handle func(f *Frame)) *dispatcher {
+	d := &dispatcher{
+		stream:   stream,
+		pending:  make(map[uint64]chan *Frame),
+		handle:   handle,
+		closedCh: make(chan struct{}),
+	}
+	go d.readLoop()
+	return d
+}
+
+func (d *dispatcher) readLoop() {
+	for {
+		f, err := d.stream.Recv()
+		if err != nil {
+			d.abort(err)
+			return
+		}
+
+		if f.Kind == KindResponse {
+			d.mu.Lock()
+			ch := d.pending[f.CallID]
+			delete(d.pending, f.CallID)
+			d.mu.Unlock()
+
+			if ch != nil {
+				ch <- f
+			}
+			continue
+		}
+
+		// handle runs on its own goroutine so readLoop keeps pulling frames off the stream
+		// while it's in flight: a handler commonly needs to make its own nested call (e.g.
+		// the sidecar answering a CallExportsFunc frame whose wasm code turns around and
+		// invokes a host import) and that nested call's response can only ever arrive via
+		// this same readLoop.
+		go d.handle(f)
+	}
+}
+
+func (d *dispatcher) abort(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return
+	}
+	d.closed = true
+	d.closeErr = err
+
+	for _, ch := range d.pending {
+		close(ch)
+	}
+	d.pending = nil
+	close(d.closedCh)
+}
+
+// Wait blocks until the stream this dispatcher reads from ends, returning the error that
+// ended it (typically io.EOF on a clean close).
+func (d *dispatcher) Wait() error {
+	<-d.closedCh
+	return d.closeErr
+}
+
+// call sends f, waits for the correlated response, and returns it (or the error it carried).
+func (d *dispatcher) call(f *Frame) (*Frame, error) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil, errors.New("remote wasm: stream closed")
+	}
+
+	f.CallID = atomic.AddUint64(&d.nextID, 1)
+	ch := make(chan *Frame, 1)
+	d.pending[f.CallID] = ch
+	d.mu.Unlock()
+
+	if err := d.send(f); err != nil {
+		d.mu.Lock()
+		delete(d.pending, f.CallID)
+		d.mu.Unlock()
+		return nil, err
+	}
+
+	resp, ok := <-ch
+	if !ok {
+		return nil, fmt.Errorf("remote wasm: stream closed waiting for call %d (%s)", f.CallID, f.Method)
+	}
+	if resp.Err != "" {
+		return nil, errors.New(resp.Err)
+	}
+	return resp, nil
+}
+
+// respond answers in with out, copying over the CallID and marking it a KindResponse.
+func (d *dispatcher) respond(in *Frame, out *Frame) error {
+	out.CallID = in.CallID
+	out.Kind = KindResponse
+	return d.send(out)
+}
+
+// fail answers in with a KindResponse carrying err's message.
+func (d *dispatcher) fail(in *Frame, err error) error {
+	return d.respond(in, &Frame{Err: err.Error()})
+}
+
+// event sends a one-way frame that expects no KindResponse.
+func (d *dispatcher) event(f *Frame) error {
+	f.Kind = KindEvent
+	return d.send(f)
+}
+
+// send serializes f onto the stream. Every Send call on a dispatcher goes through here: call,
+// respond, fail and event are all reachable concurrently (pooled instances sharing one module's
+// dispatcher, inbound frames each handled on their own goroutine), but the underlying
+// grpc.ClientStream/ServerStream only tolerate one SendMsg in flight at a time.
+func (d *dispatcher) send(f *Frame) error {
+	d.sendMu.Lock()
+	defer d.sendMu.Unlock()
+	return d.stream.Send(f)
+}
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// Kind distinguishes the four ways a Frame is used on the Exchange stream.
+type Kind int32
+
+const (
+	// KindExportCall is sent host -> sidecar: run something against the real module/instance
+	// (LoadModule, NewInstance, CallExportsFunc, GetMemory, PutMemory, Malloc, Start, Stop).
+	KindExportCall Kind = iota
+	// KindImportCall is sent sidecar -> host: invoke one ImportsHandler method on the real,
+	// in-process handler bound to InstanceID via SetImports.
+	KindImportCall
+	// KindEvent is a one-way, unsolicited frame that doesn't get a KindResponse: MOSN uses it
+	// to tell the sidecar an async HTTP/gRPC callout it dispatched on the handler's behalf has
+	// completed, so the sidecar can re-enter the real module's proxy_on_*_response export.
+	KindEvent
+	// KindResponse answers an earlier KindExportCall or KindImportCall, correlated by CallID.
+	KindResponse
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindExportCall:
+		return "ExportCall"
+	case KindImportCall:
+		return "ImportCall"
+	case KindEvent:
+		return "Event"
+	case KindResponse:
+		return "Response"
+	default:
+		return "Unknown"
+	}
+}
+
+// Frame is the single envelope type carried over the Exchange stream. Using one generic,
+// loosely-typed envelope - rather than a message per RPC - mirrors the host function calling
+// convention proxy-wasm itself already uses (a function name plus a handful of int32 args and
+// raw bytes), so dispatcher.go and host.go can stay table-driven instead of hand-marshaling a
+// distinct request/response pair per method.
+type Frame struct {
+	CallID     uint64
+	Kind       Kind
+	InstanceID uint32
+
+	// Method names the export/import being invoked, e.g. "CallExportsFunc" or "Log". Unused
+	// on KindResponse frames.
+	Method string
+
+	// Args carries int32 parameters/results in the same order the corresponding proxy-wasm
+	// function or ImportsHandler method takes/returns them.
+	Args []int32
+
+	// Bytes carries string/buffer parameters or results, in method-specific order (e.g. for
+	// CallExportsFunc's arguments, for PutMemory's content, or for a Log message).
+	Bytes [][]byte
+
+	// Err is set on a KindResponse frame to report that the call failed; empty means success.
+	Err string
+}
+
+const codecName = "gob"
+
+func init() {
+	encoding.RegisterCodec(gobCodec{})
+}
+
+// gobCodec lets the Exchange stream carry plain Go structs (Frame) without depending on a
+// .proto-generated message type: real protobuf codegen isn't available to this package, and a
+// hand-maintained imitation of protoc-gen-go's output would be its own maintenance hazard, so
+// the stream instead runs gRPC's framing/flow-control/multiplexing over gob-encoded frames,
+// selected via grpc.CallContentSubtype(codecName).
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (gobCodec) Name() string {
+	return codecName
+}
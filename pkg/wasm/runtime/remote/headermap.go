@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import "mosn.io/api"
+
+// remoteHeaderMap satisfies api.HeaderMap for a header map living on the MOSN side of an
+// Exchange stream. proxywasm_0_1_0's own host functions (see imports.go's headerMap
+// interface) only ever call Get on a header map, so that's the only method forwarded over
+// the wire; the mutating/enumerating methods are no-ops since nothing in this ABI version
+// exercises them against a sidecar-held handle. A future ABI version that needs Set/Del/Range
+// remoted would extend this the same way HeaderGet was added to host.go.
+type remoteHeaderMap struct {
+	handler *remoteImportsHandler
+	mapType int32
+}
+
+func (m *remoteHeaderMap) Get(key string) (string, bool) {
+	resp, err := m.handler.call("HeaderGet", &Frame{Args: []int32{m.mapType}, Bytes: [][]byte{[]byte(key)}})
+	if err != nil || resp.Args[0] == 0 {
+		return "", false
+	}
+	return string(resp.Bytes[0]), true
+}
+
+func (m *remoteHeaderMap) Set(key, value string) {}
+
+func (m *remoteHeaderMap) Add(key, value string) {}
+
+func (m *remoteHeaderMap) Del(key string) {}
+
+func (m *remoteHeaderMap) Range(f func(key, value string) bool) {}
+
+func (m *remoteHeaderMap) Clone() api.HeaderMap { return m }
+
+func (m *remoteHeaderMap) ByteSize() uint64 { return 0 }
@@ -0,0 +1,244 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+)
+
+// serveImportCall answers an ImportCall frame the sidecar sent on i's behalf by invoking the
+// corresponding method on the real proxywasm_0_1_0.ImportsHandler set via i.SetData - the same
+// handler a purely in-process instance would carry. This is the one place a remote instance's
+// ImportsHandler is ever touched; everything else about it (GetData/SetData, and the handler
+// implementation itself) is unchanged from the in-process case.
+//
+// GetHttpCallout and GetGrpcCallout are deliberately not handled here: remoting an async
+// callout would need the host to push an unsolicited KindEvent frame back to the sidecar once
+// the upstream response arrives, re-entering the module's proxy_on_*_response export from
+// there. That's a natural extension of this same dispatcher, but it's left as follow-up work;
+// for now a remoted plugin's proxy_http_call/proxy_grpc_call simply see GetHttpCallout/
+// GetGrpcCallout return nil, exactly like an in-process context that doesn't allow callouts.
+func (i *instance) serveImportCall(f *Frame) {
+	handler, ok := i.GetData().(proxywasm_0_1_0.ImportsHandler)
+	if !ok {
+		_ = i.disp.fail(f, fmt.Errorf("remote wasm: instance %d has no ImportsHandler bound", i.instanceID))
+		return
+	}
+
+	resp, err := dispatchImportCall(handler, i, f)
+	if err != nil {
+		_ = i.disp.fail(f, err)
+		return
+	}
+	_ = i.disp.respond(f, resp)
+}
+
+func dispatchImportCall(handler proxywasm_0_1_0.ImportsHandler, i *instance, f *Frame) (*Frame, error) {
+	switch f.Method {
+	case "Log":
+		handler.Log(log.Level(f.Args[0]), string(f.Bytes[0]))
+		return &Frame{}, nil
+
+	case "GetRootContextID":
+		return &Frame{Args: []int32{handler.GetRootContextID()}}, nil
+
+	case "GetVmID":
+		return &Frame{Bytes: [][]byte{[]byte(handler.GetVmID())}}, nil
+
+	case "GetVmConfig":
+		return &Frame{Bytes: [][]byte{bufferBytes(handler.GetVmConfig())}}, nil
+
+	case "GetPluginConfig":
+		return &Frame{Bytes: [][]byte{bufferBytes(handler.GetPluginConfig())}}, nil
+
+	case "HeaderGet":
+		return headerGet(handler, f)
+
+	case "BodyGet":
+		return bodyGet(handler, f)
+
+	case "SharedDataGet":
+		return sharedDataGet(handler, f)
+
+	case "SharedDataSet":
+		return sharedDataSet(handler, f)
+
+	case "SharedQueueRegister":
+		return sharedQueueRegister(handler, i, f)
+
+	case "SharedQueueResolve":
+		return sharedQueueResolve(handler, f)
+
+	case "SharedQueueEnqueue":
+		return sharedQueueEnqueue(handler, i, f)
+
+	case "SharedQueueDequeue":
+		return sharedQueueDequeue(handler, f)
+
+	default:
+		return nil, fmt.Errorf("remote wasm: unknown import call %q", f.Method)
+	}
+}
+
+func bufferBytes(b interface{ Bytes() []byte }) []byte {
+	if b == nil {
+		return nil
+	}
+	return b.Bytes()
+}
+
+// headerGet answers a HeaderGet import call. f.Args[0] is one of the ABI's own MapType
+// selectors (proxywasm_0_1_0.MapTypeHttpRequestHeaders, etc.); only the request/response
+// header/trailer maps are supported here since callout response maps require the callout
+// remoting this package doesn't implement yet (see serveImportCall's doc comment).
+func headerGet(handler proxywasm_0_1_0.ImportsHandler, f *Frame) (*Frame, error) {
+	var m interface {
+		Get(key string) (string, bool)
+	}
+
+	switch f.Args[0] {
+	case proxywasm_0_1_0.MapTypeHttpRequestHeaders:
+		m = handler.GetHttpRequestHeader()
+	case proxywasm_0_1_0.MapTypeHttpRequestTrailers:
+		m = handler.GetHttpRequestTrailer()
+	case proxywasm_0_1_0.MapTypeHttpResponseHeaders:
+		m = handler.GetHttpResponseHeader()
+	case proxywasm_0_1_0.MapTypeHttpResponseTrailers:
+		m = handler.GetHttpResponseTrailer()
+	default:
+		return nil, fmt.Errorf("remote wasm: unsupported header selector %d", f.Args[0])
+	}
+
+	if m == nil {
+		return &Frame{Args: []int32{0}}, nil
+	}
+
+	value, ok := m.Get(string(f.Bytes[0]))
+	okInt := int32(0)
+	if ok {
+		okInt = 1
+	}
+	return &Frame{Args: []int32{okInt}, Bytes: [][]byte{[]byte(value)}}, nil
+}
+
+// bodyGet answers a BodyGet import call, f.Args[0] being one of the ABI's own BufferType
+// selectors; only the request/response bodies are supported for the same reason headerGet
+// only supports the request/response maps.
+func bodyGet(handler proxywasm_0_1_0.ImportsHandler, f *Frame) (*Frame, error) {
+	switch f.Args[0] {
+	case proxywasm_0_1_0.BufferTypeHttpRequestBody:
+		return &Frame{Bytes: [][]byte{bufferBytes(handler.GetHttpRequestBody())}}, nil
+	case proxywasm_0_1_0.BufferTypeHttpResponseBody:
+		return &Frame{Bytes: [][]byte{bufferBytes(handler.GetHttpResponseBody())}}, nil
+	default:
+		return nil, fmt.Errorf("remote wasm: unsupported body selector %d", f.Args[0])
+	}
+}
+
+func sharedDataGet(handler proxywasm_0_1_0.ImportsHandler, f *Frame) (*Frame, error) {
+	store := handler.GetSharedDataStore()
+	if store == nil {
+		return &Frame{Args: []int32{0}}, nil
+	}
+
+	value, cas, found := store.Get(handler.GetVmID(), string(f.Bytes[0]))
+	if !found {
+		return &Frame{Args: []int32{0}}, nil
+	}
+	return &Frame{Args: []int32{1, int32(cas)}, Bytes: [][]byte{value}}, nil
+}
+
+func sharedDataSet(handler proxywasm_0_1_0.ImportsHandler, f *Frame) (*Frame, error) {
+	store := handler.GetSharedDataStore()
+	if store == nil {
+		return &Frame{Args: []int32{int32(proxywasm_0_1_0.WasmResultNotFound)}}, nil
+	}
+
+	key, value, cas := string(f.Bytes[0]), f.Bytes[1], uint32(f.Args[0])
+
+	if err := store.Set(handler.GetVmID(), key, value, cas); err != nil {
+		if errors.Is(err, proxywasm_0_1_0.ErrCasMismatch) {
+			return &Frame{Args: []int32{int32(proxywasm_0_1_0.WasmResultCasMismatch)}}, nil
+		}
+		return &Frame{Args: []int32{int32(proxywasm_0_1_0.WasmResultInternalFailure)}}, nil
+	}
+	return &Frame{Args: []int32{int32(proxywasm_0_1_0.WasmResultOk)}}, nil
+}
+
+func sharedQueueRegister(handler proxywasm_0_1_0.ImportsHandler, i *instance, f *Frame) (*Frame, error) {
+	queue := handler.GetSharedQueue()
+	if queue == nil {
+		return &Frame{Args: []int32{0}}, nil
+	}
+
+	// i itself is handed to Register as the consumer instance: Enqueue re-enters it via
+	// CallExportsFunc("proxy_on_queue_ready", ...) exactly like an in-process queue, and i's
+	// CallExportsFunc already forwards that across the wire to the sidecar that owns the real
+	// module, so the hand-off works without shared.go's sharedQueue needing to know instances
+	// can be remote.
+	queueID, err := queue.Register(handler.GetVmID(), string(f.Bytes[0]), i, handler.GetRootContextID())
+	if err != nil {
+		return nil, err
+	}
+	return &Frame{Args: []int32{1, int32(queueID)}}, nil
+}
+
+func sharedQueueResolve(handler proxywasm_0_1_0.ImportsHandler, f *Frame) (*Frame, error) {
+	queue := handler.GetSharedQueue()
+	if queue == nil {
+		return &Frame{Args: []int32{0}}, nil
+	}
+
+	queueID, ok := queue.Resolve(string(f.Bytes[0]), string(f.Bytes[1]))
+	if !ok {
+		return &Frame{Args: []int32{0}}, nil
+	}
+	return &Frame{Args: []int32{1, int32(queueID)}}, nil
+}
+
+func sharedQueueEnqueue(handler proxywasm_0_1_0.ImportsHandler, i *instance, f *Frame) (*Frame, error) {
+	queue := handler.GetSharedQueue()
+	if queue == nil {
+		return &Frame{Args: []int32{int32(proxywasm_0_1_0.WasmResultNotFound)}}, nil
+	}
+
+	if err := queue.Enqueue(uint32(f.Args[0]), f.Bytes[0], i); err != nil {
+		if errors.Is(err, proxywasm_0_1_0.ErrQueueNotFound) {
+			return &Frame{Args: []int32{int32(proxywasm_0_1_0.WasmResultNotFound)}}, nil
+		}
+		return &Frame{Args: []int32{int32(proxywasm_0_1_0.WasmResultInternalFailure)}}, nil
+	}
+	return &Frame{Args: []int32{int32(proxywasm_0_1_0.WasmResultOk)}}, nil
+}
+
+func sharedQueueDequeue(handler proxywasm_0_1_0.ImportsHandler, f *Frame) (*Frame, error) {
+	queue := handler.GetSharedQueue()
+	if queue == nil {
+		return &Frame{Args: []int32{0}}, nil
+	}
+
+	value, ok := queue.Dequeue(uint32(f.Args[0]))
+	if !ok {
+		return &Frame{Args: []int32{0}}, nil
+	}
+	return &Frame{Args: []int32{1}, Bytes: [][]byte{value}}, nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"fmt"
+	"sync"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// instance is MOSN's types.WasmInstance stub for one instance living in a sidecar. Every
+// method that would normally touch local linear memory or the module's exports instead
+// round-trips an ExportCall frame over module.disp; GetData/SetData stay local since the real
+// ImportsHandler (e.g. a filter's callback implementation) only ever needs to be reachable
+// from host.go's ImportCall dispatch, never from the sidecar directly.
+type instance struct {
+	module     *module
+	instanceID uint32
+	disp       *dispatcher
+
+	mux     sync.Mutex
+	abi     types.ABI
+	data    interface{}
+	stopped bool
+}
+
+func (i *instance) Acquire(abi types.ABI) bool {
+	i.mux.Lock()
+	if i.stopped {
+		i.mux.Unlock()
+		return false
+	}
+	i.abi = abi
+	return true
+}
+
+func (i *instance) Release() {
+	i.abi = nil
+	i.mux.Unlock()
+}
+
+func (i *instance) Start() error {
+	_, err := i.disp.call(&Frame{Kind: KindExportCall, InstanceID: i.instanceID, Method: "Start"})
+	return err
+}
+
+func (i *instance) Stop() {
+	i.mux.Lock()
+	i.stopped = true
+	i.mux.Unlock()
+
+	_, _ = i.disp.call(&Frame{Kind: KindExportCall, InstanceID: i.instanceID, Method: "Stop"})
+}
+
+func (i *instance) GetModule() types.WasmModule {
+	return i.module
+}
+
+func (i *instance) CallExportsFunc(name string, args ...int32) (int32, error) {
+	resp, err := i.disp.call(&Frame{
+		Kind:       KindExportCall,
+		InstanceID: i.instanceID,
+		Method:     "CallExportsFunc",
+		Args:       args,
+		Bytes:      [][]byte{[]byte(name)},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("remote wasm: call %s: %v", name, err)
+	}
+	if len(resp.Args) == 0 {
+		return 0, nil
+	}
+	return resp.Args[0], nil
+}
+
+func (i *instance) GetMemory(addr uint64, size uint64) ([]byte, error) {
+	resp, err := i.disp.call(&Frame{
+		Kind:       KindExportCall,
+		InstanceID: i.instanceID,
+		Method:     "GetMemory",
+		Args:       []int32{int32(addr), int32(size)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("remote wasm: GetMemory(%d, %d): %v", addr, size, err)
+	}
+	if len(resp.Bytes) == 0 {
+		return nil, nil
+	}
+	return resp.Bytes[0], nil
+}
+
+func (i *instance) PutMemory(addr uint64, content []byte) error {
+	_, err := i.disp.call(&Frame{
+		Kind:       KindExportCall,
+		InstanceID: i.instanceID,
+		Method:     "PutMemory",
+		Args:       []int32{int32(addr)},
+		Bytes:      [][]byte{content},
+	})
+	if err != nil {
+		return fmt.Errorf("remote wasm: PutMemory(%d): %v", addr, err)
+	}
+	return nil
+}
+
+func (i *instance) Malloc(size int32) (uint64, error) {
+	resp, err := i.disp.call(&Frame{
+		Kind:       KindExportCall,
+		InstanceID: i.instanceID,
+		Method:     "Malloc",
+		Args:       []int32{size},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("remote wasm: Malloc(%d): %v", size, err)
+	}
+	return uint64(resp.Args[0]), nil
+}
+
+func (i *instance) GetData() interface{} {
+	return i.data
+}
+
+func (i *instance) SetData(data interface{}) {
+	i.data = data
+}
@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+// module is MOSN's handle on a module loaded into a sidecar: one gRPC stream, multiplexing
+// every instance created from it by InstanceID.
+type module struct {
+	conn   *grpc.ClientConn
+	disp   *dispatcher
+	cancel context.CancelFunc
+
+	nextInstanceID uint32
+	instances      sync.Map // uint32 -> *instance
+}
+
+// dialModule opens the Exchange stream to address and asks the sidecar to compile wasmBytes
+// under abiVersion, returning a module bound to that stream once the sidecar acknowledges it.
+func dialModule(address string, wasmBytes []byte, abiVersion string) (*module, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	conn, stream, err := openExchangeStream(ctx, address)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	m := &module{conn: conn, cancel: cancel}
+	m.disp = newDispatcher(&clientFrameStream{stream}, m.handleIncoming)
+
+	_, err = m.disp.call(&Frame{
+		Kind:   KindExportCall,
+		Method: "LoadModule",
+		Bytes:  [][]byte{wasmBytes, []byte(abiVersion)},
+	})
+	if err != nil {
+		m.Close()
+		return nil, fmt.Errorf("remote wasm: LoadModule: %v", err)
+	}
+
+	return m, nil
+}
+
+func (m *module) NewInstance() types.WasmInstance {
+	id := atomic.AddUint32(&m.nextInstanceID, 1)
+
+	if _, err := m.disp.call(&Frame{Kind: KindExportCall, InstanceID: id, Method: "NewInstance"}); err != nil {
+		log.DefaultLogger.Errorf("[wasm][remote] NewInstance: %v", err)
+		return nil
+	}
+
+	i := &instance{module: m, instanceID: id, disp: m.disp}
+	m.instances.Store(id, i)
+	return i
+}
+
+func (m *module) Close() {
+	if m.disp != nil {
+		_, _ = m.disp.call(&Frame{Kind: KindExportCall, Method: "CloseModule"})
+	}
+	m.cancel()
+	m.conn.Close()
+}
+
+// handleIncoming serves frames the sidecar initiates: today that is exclusively ImportCall,
+// routed to the instance it names so the real ImportsHandler bound to that instance (via
+// SetImports, same as an in-process plugin) can answer it.
+func (m *module) handleIncoming(f *Frame) {
+	if f.Kind != KindImportCall {
+		log.DefaultLogger.Errorf("[wasm][remote] unexpected frame kind %s from sidecar", f.Kind)
+		return
+	}
+
+	v, ok := m.instances.Load(f.InstanceID)
+	if !ok {
+		_ = m.disp.fail(f, fmt.Errorf("remote wasm: unknown instance %d", f.InstanceID))
+		return
+	}
+
+	v.(*instance).serveImportCall(f)
+}
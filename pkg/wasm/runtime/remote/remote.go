@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package remote registers MOSN's "remote" wasm engine: instead of compiling and running a
+// module in this process, it dials an external sidecar (see ./sidecar for a reference
+// implementation hosting wasmer) over a single gRPC stream and drives the module through it.
+// This trades the per-call latency of an RPC for fault isolation - a plugin that panics or
+// leaks memory takes down the sidecar, not MOSN - which matters for heavy or less-trusted
+// third-party plugins.
+//
+// The stream carries one envelope type, Frame (see frame.go): MOSN sends ExportCall frames
+// to run the module's proxy_on_* exports or touch its linear memory, and the sidecar sends
+// ImportCall frames to invoke the real ImportsHandler methods that only make sense in MOSN's
+// process (the live HTTP request, the cluster manager, shared data/queues). Both directions
+// are multiplexed over the same stream by Frame.CallID, exactly like a synchronous RPC - see
+// dispatcher.go.
+//
+// On the MOSN side, instance (instance.go) is a types.WasmInstance whose GetMemory/PutMemory/
+// Malloc/CallExportsFunc are simply forwarded as ExportCall frames: every other layer (abi.
+// GetABI, the proxywasm_0_1_0 host functions, WasmPluginWrapper) already goes through those
+// four methods without assuming the memory they touch is local, so a remote instance slots in
+// as a drop-in types.WasmInstance with no changes required anywhere else.
+package remote
+
+import (
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/wasm"
+)
+
+// EngineName is the WasmVmConfig.Engine value that selects this package's engine.
+const EngineName = "remote"
+
+func init() {
+	wasm.RegisterWasmEngine(EngineName, NewWasmVM())
+}
+
+type vm struct{}
+
+// NewWasmVM constructs the remote engine. It is exported mainly so tests can construct one
+// without going through the engine registry.
+func NewWasmVM() types.WasmVM {
+	return &vm{}
+}
+
+func (v *vm) Name() string {
+	return EngineName
+}
+
+func (v *vm) Init() error {
+	return nil
+}
+
+// NewModule satisfies types.WasmVM, but the remote engine has no address to dial without a
+// WasmVmConfig.Address, which this signature doesn't carry. wasm.AddOrUpdateWasm detects
+// types.AddressedWasmVM and calls NewModuleAt instead; this is only reachable if some other
+// caller invokes the engine directly.
+func (v *vm) NewModule(wasmBytes []byte, abiVersion string) types.WasmModule {
+	return v.NewModuleAt("", wasmBytes, abiVersion)
+}
+
+// NewModuleAt dials address, asks the sidecar listening there to load wasmBytes under
+// abiVersion, and returns a WasmModule bound to that one gRPC stream.
+func (v *vm) NewModuleAt(address string, wasmBytes []byte, abiVersion string) types.WasmModule {
+	m, err := dialModule(address, wasmBytes, abiVersion)
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+func (v *vm) Close() {}
@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+)
+
+// These three constants are what protoc-gen-go-grpc would otherwise derive from a .proto
+// file; ProxyWasmTransport has exactly one RPC, so they're spelled out by hand instead of
+// carrying a protobuf toolchain dependency for it.
+const (
+	serviceName      = "mosn.wasm.remote.ProxyWasmTransport"
+	exchangeStream   = "Exchange"
+	exchangeFullName = "/" + serviceName + "/" + exchangeStream
+)
+
+// TransportServer is implemented by whatever serves the sidecar side of the Exchange stream;
+// Sidecar (sidecar.go) is this package's own implementation, wired to a real wasm engine.
+type TransportServer interface {
+	Exchange(stream grpc.ServerStream) error
+}
+
+// RegisterTransportServer registers impl on s so it serves Exchange calls. Sidecar main
+// packages (see ./sidecar) call this instead of reaching into serviceDesc directly.
+func RegisterTransportServer(s *grpc.Server, impl TransportServer) {
+	s.RegisterService(&serviceDesc, impl)
+}
+
+// serviceDesc registers the single bidi-streaming Exchange method. Handed to grpc.Server.
+// RegisterService by RegisterTransportServer.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*TransportServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    exchangeStream,
+			Handler:       exchangeHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "mosn/wasm/runtime/remote",
+}
+
+func exchangeHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TransportServer).Exchange(stream)
+}
+
+// frameStream is the minimal send/recv surface dispatcher needs; both grpc.ClientStream and
+// grpc.ServerStream satisfy it once wrapped below, since both already expose a generic
+// SendMsg(interface{})/RecvMsg(interface{}) pair that the gob codec marshals Frame through.
+type frameStream interface {
+	Send(*Frame) error
+	Recv() (*Frame, error)
+}
+
+type clientFrameStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientFrameStream) Send(f *Frame) error {
+	return s.SendMsg(f)
+}
+
+func (s *clientFrameStream) Recv() (*Frame, error) {
+	f := new(Frame)
+	if err := s.RecvMsg(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type serverFrameStream struct {
+	grpc.ServerStream
+}
+
+func (s *serverFrameStream) Send(f *Frame) error {
+	return s.SendMsg(f)
+}
+
+func (s *serverFrameStream) Recv() (*Frame, error) {
+	f := new(Frame)
+	if err := s.RecvMsg(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// openExchangeStream dials address and opens the Exchange stream MOSN drives a module
+// through, selecting the gob codec so Frame doesn't need a protobuf message type.
+func openExchangeStream(ctx context.Context, address string) (*grpc.ClientConn, grpc.ClientStream, error) {
+	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("remote wasm: dial %s: %v", address, err)
+	}
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{
+		StreamName:    exchangeStream,
+		ServerStreams: true,
+		ClientStreams: true,
+	}, exchangeFullName)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("remote wasm: open %s: %v", exchangeStream, err)
+	}
+
+	return conn, stream, nil
+}
@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"fmt"
+
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+)
+
+// remoteSharedDataStore and remoteSharedQueue satisfy proxywasm_0_1_0.SharedDataStore/
+// SharedQueue for a module running in the sidecar, forwarding every call to MOSN's real
+// store/queue (pkg/wasm/shared.go) via host.go's SharedData*/SharedQueue* import calls.
+// vmID is never put on the wire: the MOSN side already has the real ImportsHandler's own
+// GetVmID() available locally in host.go, which is always the same vmID the sidecar's own
+// GetVmID() forwarded back in the first place.
+type remoteSharedDataStore struct {
+	handler *remoteImportsHandler
+}
+
+func (s *remoteSharedDataStore) Get(vmID string, key string) (value []byte, cas uint32, ok bool) {
+	resp, err := s.handler.call("SharedDataGet", &Frame{Bytes: [][]byte{[]byte(key)}})
+	if err != nil || resp.Args[0] == 0 {
+		return nil, 0, false
+	}
+	return resp.Bytes[0], uint32(resp.Args[1]), true
+}
+
+func (s *remoteSharedDataStore) Set(vmID string, key string, value []byte, cas uint32) error {
+	resp, err := s.handler.call("SharedDataSet", &Frame{
+		Args:  []int32{int32(cas)},
+		Bytes: [][]byte{[]byte(key), value},
+	})
+	if err != nil {
+		return err
+	}
+
+	switch resp.Args[0] {
+	case proxywasm_0_1_0.WasmResultOk:
+		return nil
+	case proxywasm_0_1_0.WasmResultCasMismatch:
+		return proxywasm_0_1_0.ErrCasMismatch
+	default:
+		return fmt.Errorf("remote wasm: SharedDataSet failed, result=%d", resp.Args[0])
+	}
+}
+
+type remoteSharedQueue struct {
+	handler *remoteImportsHandler
+}
+
+// Register doesn't forward instance or rootContextID: host.go's sharedQueueRegister already
+// has both available locally (handler.GetRootContextID(), and i itself as the consumer to
+// register), since it's answering this call on MOSN's side of the wire in the first place.
+func (q *remoteSharedQueue) Register(vmID string, queueName string, instance types.WasmInstance, rootContextID int32) (queueID uint32, err error) {
+	resp, err := q.handler.call("SharedQueueRegister", &Frame{Bytes: [][]byte{[]byte(queueName)}})
+	if err != nil {
+		return 0, err
+	}
+	if resp.Args[0] == 0 {
+		return 0, fmt.Errorf("remote wasm: register queue %q failed", queueName)
+	}
+	return uint32(resp.Args[1]), nil
+}
+
+func (q *remoteSharedQueue) Resolve(vmID string, queueName string) (queueID uint32, ok bool) {
+	resp, err := q.handler.call("SharedQueueResolve", &Frame{Bytes: [][]byte{[]byte(vmID), []byte(queueName)}})
+	if err != nil || resp.Args[0] == 0 {
+		return 0, false
+	}
+	return uint32(resp.Args[1]), true
+}
+
+// Enqueue doesn't forward producer: host.go's sharedQueueEnqueue already has i, the instance
+// answering this call on MOSN's side of the wire, available locally as the real producer.
+func (q *remoteSharedQueue) Enqueue(queueID uint32, data []byte, producer types.WasmInstance) error {
+	resp, err := q.handler.call("SharedQueueEnqueue", &Frame{Args: []int32{int32(queueID)}, Bytes: [][]byte{data}})
+	if err != nil {
+		return err
+	}
+
+	switch resp.Args[0] {
+	case proxywasm_0_1_0.WasmResultOk:
+		return nil
+	case proxywasm_0_1_0.WasmResultNotFound:
+		return proxywasm_0_1_0.ErrQueueNotFound
+	default:
+		return fmt.Errorf("remote wasm: SharedQueueEnqueue failed, result=%d", resp.Args[0])
+	}
+}
+
+func (q *remoteSharedQueue) Dequeue(queueID uint32) (data []byte, ok bool) {
+	resp, err := q.handler.call("SharedQueueDequeue", &Frame{Args: []int32{int32(queueID)}})
+	if err != nil || resp.Args[0] == 0 {
+		return nil, false
+	}
+	return resp.Bytes[0], true
+}
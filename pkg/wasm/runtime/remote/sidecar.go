@@ -0,0 +1,366 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/wasm/abi"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+	"mosn.io/pkg/buffer"
+)
+
+// Sidecar serves the host side of the Exchange RPC on behalf of an out-of-process wasm
+// engine, e.g. the real wasmer runtime (see ./sidecar for a binary wiring this up). One
+// Sidecar instance can back any number of concurrent Exchange streams, each of which loads
+// and drives exactly one module on MOSN's behalf.
+type Sidecar struct {
+	vm types.WasmVM
+}
+
+// NewSidecar wraps vm (already Init'd) so it can serve Exchange streams.
+func NewSidecar(vm types.WasmVM) *Sidecar {
+	return &Sidecar{vm: vm}
+}
+
+// Register adds the Exchange service to s.
+func (sc *Sidecar) Register(s *grpc.Server) {
+	RegisterTransportServer(s, sc)
+}
+
+// Exchange serves one MOSN-opened stream for the lifetime of the connection: it loads the
+// single module the first LoadModule frame names, then answers every ExportCall frame
+// against it until the stream closes.
+func (sc *Sidecar) Exchange(stream grpc.ServerStream) error {
+	sess := &sidecarSession{vm: sc.vm}
+	sess.disp = newDispatcher(&serverFrameStream{stream}, sess.handle)
+	return sess.disp.Wait()
+}
+
+// sidecarSession is the sidecar-side counterpart of module.go's module: the state backing
+// one Exchange stream, keyed the same way (by Frame.InstanceID) MOSN keys its own instances
+// map.
+type sidecarSession struct {
+	vm   types.WasmVM
+	disp *dispatcher
+
+	mux        sync.Mutex
+	module     types.WasmModule
+	abiVersion string
+
+	instances sync.Map // uint32 -> *sidecarInstance
+}
+
+type sidecarInstance struct {
+	instance types.WasmInstance
+	abi      types.ABI
+}
+
+// handle answers an ExportCall frame MOSN sent. Every case here mirrors one method of
+// types.WasmModule/types.WasmInstance, exactly the set pkg/wasm/runtime/remote/instance.go
+// and module.go forward as ExportCall frames.
+func (sess *sidecarSession) handle(f *Frame) {
+	if f.Kind != KindExportCall {
+		_ = sess.disp.fail(f, fmt.Errorf("remote wasm sidecar: unexpected frame kind %s", f.Kind))
+		return
+	}
+
+	switch f.Method {
+	case "LoadModule":
+		sess.loadModule(f)
+	case "NewInstance":
+		sess.newInstance(f)
+	case "CallExportsFunc":
+		sess.callExportsFunc(f)
+	case "GetMemory":
+		sess.getMemory(f)
+	case "PutMemory":
+		sess.putMemory(f)
+	case "Malloc":
+		sess.malloc(f)
+	case "Start":
+		sess.start(f)
+	case "Stop":
+		sess.stop(f)
+	case "CloseModule":
+		sess.closeModule(f)
+	default:
+		_ = sess.disp.fail(f, fmt.Errorf("remote wasm sidecar: unknown export call %q", f.Method))
+	}
+}
+
+func (sess *sidecarSession) loadModule(f *Frame) {
+	wasmBytes, abiVersion := f.Bytes[0], string(f.Bytes[1])
+
+	module := sess.vm.NewModule(wasmBytes, abiVersion)
+	if module == nil {
+		_ = sess.disp.fail(f, errors.New("remote wasm sidecar: compile failed"))
+		return
+	}
+
+	sess.mux.Lock()
+	sess.module = module
+	sess.abiVersion = abiVersion
+	sess.mux.Unlock()
+
+	_ = sess.disp.respond(f, &Frame{})
+}
+
+func (sess *sidecarSession) newInstance(f *Frame) {
+	sess.mux.Lock()
+	module, abiVersion := sess.module, sess.abiVersion
+	sess.mux.Unlock()
+
+	if module == nil {
+		_ = sess.disp.fail(f, errors.New("remote wasm sidecar: NewInstance before LoadModule"))
+		return
+	}
+
+	instance := module.NewInstance()
+	if instance == nil {
+		_ = sess.disp.fail(f, errors.New("remote wasm sidecar: instantiate failed"))
+		return
+	}
+
+	instanceABI := abi.GetABI(instance, abiVersion)
+	if instanceABI == nil {
+		_ = sess.disp.fail(f, fmt.Errorf("remote wasm sidecar: unknown abi version %s", abiVersion))
+		return
+	}
+
+	// The ImportsHandler bound here forwards every call back to MOSN as a KindImportCall
+	// frame; this instance is never Acquire()'d locally because the mutual exclusion that
+	// matters - not driving this same instance from two ExportCall frames at once - is
+	// already enforced by the MOSN-side remote.instance's own Acquire/Release around
+	// whichever ABI call sent the frame in the first place.
+	instanceABI.SetImports(&remoteImportsHandler{session: sess, instanceID: f.InstanceID})
+
+	sess.instances.Store(f.InstanceID, &sidecarInstance{instance: instance, abi: instanceABI})
+	_ = sess.disp.respond(f, &Frame{})
+}
+
+func (sess *sidecarSession) lookup(f *Frame) (types.WasmInstance, bool) {
+	v, ok := sess.instances.Load(f.InstanceID)
+	if !ok {
+		_ = sess.disp.fail(f, fmt.Errorf("remote wasm sidecar: unknown instance %d", f.InstanceID))
+		return nil, false
+	}
+	return v.(*sidecarInstance).instance, true
+}
+
+func (sess *sidecarSession) callExportsFunc(f *Frame) {
+	instance, ok := sess.lookup(f)
+	if !ok {
+		return
+	}
+
+	ret, err := instance.CallExportsFunc(string(f.Bytes[0]), f.Args...)
+	if err != nil {
+		_ = sess.disp.fail(f, err)
+		return
+	}
+	_ = sess.disp.respond(f, &Frame{Args: []int32{ret}})
+}
+
+func (sess *sidecarSession) getMemory(f *Frame) {
+	instance, ok := sess.lookup(f)
+	if !ok {
+		return
+	}
+
+	data, err := instance.GetMemory(uint64(f.Args[0]), uint64(f.Args[1]))
+	if err != nil {
+		_ = sess.disp.fail(f, err)
+		return
+	}
+	_ = sess.disp.respond(f, &Frame{Bytes: [][]byte{data}})
+}
+
+func (sess *sidecarSession) putMemory(f *Frame) {
+	instance, ok := sess.lookup(f)
+	if !ok {
+		return
+	}
+
+	if err := instance.PutMemory(uint64(f.Args[0]), f.Bytes[0]); err != nil {
+		_ = sess.disp.fail(f, err)
+		return
+	}
+	_ = sess.disp.respond(f, &Frame{})
+}
+
+func (sess *sidecarSession) malloc(f *Frame) {
+	instance, ok := sess.lookup(f)
+	if !ok {
+		return
+	}
+
+	addr, err := instance.Malloc(f.Args[0])
+	if err != nil {
+		_ = sess.disp.fail(f, err)
+		return
+	}
+	_ = sess.disp.respond(f, &Frame{Args: []int32{int32(addr)}})
+}
+
+func (sess *sidecarSession) start(f *Frame) {
+	instance, ok := sess.lookup(f)
+	if !ok {
+		return
+	}
+
+	if err := instance.Start(); err != nil {
+		_ = sess.disp.fail(f, err)
+		return
+	}
+	_ = sess.disp.respond(f, &Frame{})
+}
+
+func (sess *sidecarSession) stop(f *Frame) {
+	instance, ok := sess.lookup(f)
+	if !ok {
+		return
+	}
+
+	instance.Stop()
+	sess.instances.Delete(f.InstanceID)
+	_ = sess.disp.respond(f, &Frame{})
+}
+
+func (sess *sidecarSession) closeModule(f *Frame) {
+	sess.mux.Lock()
+	module := sess.module
+	sess.mux.Unlock()
+
+	if module != nil {
+		module.Close()
+	}
+	_ = sess.disp.respond(f, &Frame{})
+}
+
+// remoteImportsHandler is the ImportsHandler bound to a module running in the sidecar: every
+// method forwards to MOSN as a KindImportCall frame over the same stream the module's
+// ExportCall frames arrived on, so host.go's dispatchImportCall can answer it against the
+// real, in-process ImportsHandler MOSN's own filter/stream layer provides.
+//
+// GetHttpCallout and GetGrpcCallout are not forwarded: remoting an async callout would need
+// MOSN to push an unsolicited KindEvent frame back once the upstream response arrives, so the
+// sidecar can re-enter the module's proxy_on_*_response export from there. That's a natural
+// extension of this same dispatcher but is left as follow-up work; for now a remoted plugin's
+// proxy_http_call/proxy_grpc_call see these return nil, exactly like an in-process context
+// that doesn't allow callouts.
+type remoteImportsHandler struct {
+	proxywasm_0_1_0.DefaultInstanceCallback
+
+	session    *sidecarSession
+	instanceID uint32
+}
+
+func (h *remoteImportsHandler) call(method string, f *Frame) (*Frame, error) {
+	f.Kind = KindImportCall
+	f.InstanceID = h.instanceID
+	f.Method = method
+	return h.session.disp.call(f)
+}
+
+func (h *remoteImportsHandler) GetRootContextID() int32 {
+	resp, err := h.call("GetRootContextID", &Frame{})
+	if err != nil {
+		return 0
+	}
+	return resp.Args[0]
+}
+
+func (h *remoteImportsHandler) GetVmID() string {
+	resp, err := h.call("GetVmID", &Frame{})
+	if err != nil {
+		return ""
+	}
+	return string(resp.Bytes[0])
+}
+
+func (h *remoteImportsHandler) GetVmConfig() buffer.IoBuffer {
+	return h.getBuffer("GetVmConfig")
+}
+
+func (h *remoteImportsHandler) GetPluginConfig() buffer.IoBuffer {
+	return h.getBuffer("GetPluginConfig")
+}
+
+func (h *remoteImportsHandler) getBuffer(method string) buffer.IoBuffer {
+	resp, err := h.call(method, &Frame{})
+	if err != nil || len(resp.Bytes[0]) == 0 {
+		return nil
+	}
+	return buffer.NewIoBufferBytes(resp.Bytes[0])
+}
+
+func (h *remoteImportsHandler) GetHttpRequestHeader() api.HeaderMap {
+	return h.headerMap(proxywasm_0_1_0.MapTypeHttpRequestHeaders)
+}
+
+func (h *remoteImportsHandler) GetHttpRequestTrailer() api.HeaderMap {
+	return h.headerMap(proxywasm_0_1_0.MapTypeHttpRequestTrailers)
+}
+
+func (h *remoteImportsHandler) GetHttpResponseHeader() api.HeaderMap {
+	return h.headerMap(proxywasm_0_1_0.MapTypeHttpResponseHeaders)
+}
+
+func (h *remoteImportsHandler) GetHttpResponseTrailer() api.HeaderMap {
+	return h.headerMap(proxywasm_0_1_0.MapTypeHttpResponseTrailers)
+}
+
+func (h *remoteImportsHandler) headerMap(mapType int32) api.HeaderMap {
+	return &remoteHeaderMap{handler: h, mapType: mapType}
+}
+
+func (h *remoteImportsHandler) GetHttpRequestBody() buffer.IoBuffer {
+	return h.body(proxywasm_0_1_0.BufferTypeHttpRequestBody)
+}
+
+func (h *remoteImportsHandler) GetHttpResponseBody() buffer.IoBuffer {
+	return h.body(proxywasm_0_1_0.BufferTypeHttpResponseBody)
+}
+
+func (h *remoteImportsHandler) body(bufferType int32) buffer.IoBuffer {
+	resp, err := h.call("BodyGet", &Frame{Args: []int32{bufferType}})
+	if err != nil || len(resp.Bytes) == 0 || len(resp.Bytes[0]) == 0 {
+		return nil
+	}
+	return buffer.NewIoBufferBytes(resp.Bytes[0])
+}
+
+func (h *remoteImportsHandler) Log(level log.Level, msg string) {
+	_, _ = h.call("Log", &Frame{Args: []int32{int32(level)}, Bytes: [][]byte{[]byte(msg)}})
+}
+
+func (h *remoteImportsHandler) GetSharedDataStore() proxywasm_0_1_0.SharedDataStore {
+	return &remoteSharedDataStore{handler: h}
+}
+
+func (h *remoteImportsHandler) GetSharedQueue() proxywasm_0_1_0.SharedQueue {
+	return &remoteSharedQueue{handler: h}
+}
@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command sidecar is the reference out-of-process wasm runtime for the "remote" engine
+// (mosn.io/mosn/pkg/wasm/runtime/remote): it hosts a real wasmer VM and serves the Exchange
+// RPC so a MOSN process can load and drive modules in it instead of in-process.
+//
+//	sidecar -listen 127.0.0.1:9700
+package main
+
+import (
+	"flag"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/wasm/runtime/remote"
+	"mosn.io/mosn/pkg/wasm/runtime/wasmer"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:9700", "address to serve the Exchange RPC on")
+	flag.Parse()
+
+	vm := wasmer.NewWasmVM()
+	if err := vm.Init(); err != nil {
+		log.DefaultLogger.Errorf("[wasm][sidecar] init wasmer: %v", err)
+		os.Exit(1)
+	}
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.DefaultLogger.Errorf("[wasm][sidecar] listen %s: %v", *listen, err)
+		os.Exit(1)
+	}
+
+	server := grpc.NewServer()
+	remote.NewSidecar(vm).Register(server)
+
+	log.DefaultLogger.Infof("[wasm][sidecar] serving on %s", *listen)
+	if err := server.Serve(lis); err != nil {
+		log.DefaultLogger.Errorf("[wasm][sidecar] serve: %v", err)
+		os.Exit(1)
+	}
+}
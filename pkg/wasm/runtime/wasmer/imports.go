@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasmer
+
+import (
+	"fmt"
+
+	"github.com/wasmerio/wasmer-go/wasmer"
+
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_2_0"
+)
+
+// hostFunctionsFor resolves the proxy_* host function table for abiVersion, the same switch
+// newImportObject uses, so newModule can fail fast on an unknown abi version before any
+// instance exists.
+func hostFunctionsFor(abiVersion string) (map[string]proxywasm_0_1_0.HostFunction, error) {
+	switch abiVersion {
+	case "", proxywasm_0_1_0.ProxyWasmABI_0_1_0:
+		return proxywasm_0_1_0.HostFunctions(), nil
+	case proxywasm_0_2_0.ProxyWasmABI_0_2_0:
+		return proxywasm_0_2_0.HostFunctions(), nil
+	default:
+		return nil, fmt.Errorf("wasmer: unknown abi version %s", abiVersion)
+	}
+}
+
+// newImportObject builds the "env" import namespace for the given proxy-wasm ABI version,
+// wiring each proxy_* host function to the wasmer calling convention. caller is instantiated
+// fresh for every instance (see newInstance) and is captured directly by each closure, so
+// concurrently running instances never share mutable state to resolve which one a host call
+// belongs to.
+func newImportObject(store *wasmer.Store, abiVersion string, caller *instance) (*wasmer.ImportObject, error) {
+	hostFuncs, err := hostFunctionsFor(abiVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := wasmer.NewImportObject()
+
+	namespace := map[string]wasmer.IntoExtern{}
+	for name, fn := range hostFuncs {
+		namespace[name] = wrapHostFunction(store, name, caller, fn)
+	}
+
+	imports.Register("env", namespace)
+	return imports, nil
+}
+
+// importArity is the i32 parameter count each proxy_* import declares, per the proxy-wasm
+// ABI. Imports not listed default to 5, which covers every getter-style function.
+var importArity = map[string]int{
+	"proxy_http_call":             10,
+	"proxy_grpc_call":             12,
+	"proxy_grpc_stream":           10,
+	"proxy_grpc_send":             4,
+	"proxy_grpc_cancel":           1,
+	"proxy_grpc_close":            1,
+	"proxy_register_shared_queue": 3,
+	"proxy_enqueue_shared_queue":  3,
+	"proxy_dequeue_shared_queue":  3,
+}
+
+func arityOf(name string) int {
+	if n, ok := importArity[name]; ok {
+		return n
+	}
+	return 5
+}
+
+// wrapHostFunction adapts a proxywasm_0_1_0.HostFunction (Go-native int32 args/result) to a
+// wasmer.Function (wasmer.Value args/result). caller is the one instance this import object
+// was built for (see newImportObject), captured directly by the closure instead of resolved
+// through any shared state.
+func wrapHostFunction(store *wasmer.Store, name string, caller *instance, fn proxywasm_0_1_0.HostFunction) *wasmer.Function {
+	params := make([]wasmer.ValueKind, arityOf(name))
+	for i := range params {
+		params[i] = wasmer.I32
+	}
+
+	sig := wasmer.NewFunctionType(
+		wasmer.NewValueTypes(params...),
+		wasmer.NewValueTypes(wasmer.I32),
+	)
+
+	return wasmer.NewFunction(store, sig, func(args []wasmer.Value) ([]wasmer.Value, error) {
+		if caller == nil {
+			return []wasmer.Value{wasmer.NewI32(0)}, nil
+		}
+
+		intArgs := make([]int32, len(args))
+		for i, a := range args {
+			intArgs[i] = a.I32()
+		}
+
+		result := fn(caller, intArgs...)
+		return []wasmer.Value{wasmer.NewI32(result)}, nil
+	})
+}
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasmer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wasmerio/wasmer-go/wasmer"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// instance wraps a single wasmer.Instance, serializing access through lock since linear
+// memory and the instance's call stack are not safe for concurrent use.
+type instance struct {
+	mux      sync.Mutex
+	module   *module
+	instance *wasmer.Instance
+	memory   *wasmer.Memory
+	abi      types.ABI
+	data     interface{}
+	stopped  bool
+}
+
+func newInstance(m *module) (*instance, error) {
+	i := &instance{module: m}
+
+	// Each instance gets its own import object, built against i itself, rather than the whole
+	// module sharing one: a shared import object's closures would have no way to tell which of
+	// several concurrently-running instances a host call belongs to without some piece of
+	// mutable shared state (the bug this replaced - see imports.go's wrapHostFunction).
+	imports, err := newImportObject(m.vm.store, m.abiVersion, i)
+	if err != nil {
+		return nil, fmt.Errorf("wasmer: build imports: %v", err)
+	}
+
+	wi, err := wasmer.NewInstance(m.module, imports)
+	if err != nil {
+		return nil, fmt.Errorf("wasmer: instantiate: %v", err)
+	}
+
+	mem, err := wi.Exports.GetMemory("memory")
+	if err != nil {
+		return nil, fmt.Errorf("wasmer: missing exported memory: %v", err)
+	}
+
+	i.instance = wi
+	i.memory = mem
+	return i, nil
+}
+
+func (i *instance) Acquire(abi types.ABI) bool {
+	i.mux.Lock()
+	if i.stopped {
+		i.mux.Unlock()
+		return false
+	}
+	i.abi = abi
+	return true
+}
+
+func (i *instance) Release() {
+	i.abi = nil
+	i.mux.Unlock()
+}
+
+func (i *instance) Start() error {
+	start, err := i.instance.Exports.GetFunction("_start")
+	if err != nil {
+		// not every module exports a start function, that's fine.
+		return nil
+	}
+	_, err = start()
+	return err
+}
+
+func (i *instance) Stop() {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	i.stopped = true
+	i.instance.Close()
+}
+
+func (i *instance) GetModule() types.WasmModule {
+	return i.module
+}
+
+func (i *instance) CallExportsFunc(name string, args ...int32) (int32, error) {
+	fn, err := i.instance.Exports.GetFunction(name)
+	if err != nil {
+		return 0, fmt.Errorf("wasmer: no such export %s: %v", name, err)
+	}
+
+	wasmArgs := make([]interface{}, len(args))
+	for idx, a := range args {
+		wasmArgs[idx] = a
+	}
+
+	ret, err := fn(wasmArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("wasmer: call %s: %v", name, err)
+	}
+
+	if ret == nil {
+		return 0, nil
+	}
+	return ret.(int32), nil
+}
+
+func (i *instance) GetMemory(addr uint64, size uint64) ([]byte, error) {
+	data := i.memory.Data()
+	if addr+size > uint64(len(data)) {
+		return nil, fmt.Errorf("wasmer: out of bounds memory access at %d, size %d", addr, size)
+	}
+
+	out := make([]byte, size)
+	copy(out, data[addr:addr+size])
+	return out, nil
+}
+
+func (i *instance) PutMemory(addr uint64, content []byte) error {
+	data := i.memory.Data()
+	if addr+uint64(len(content)) > uint64(len(data)) {
+		return fmt.Errorf("wasmer: out of bounds memory write at %d, size %d", addr, len(content))
+	}
+
+	copy(data[addr:], content)
+	return nil
+}
+
+func (i *instance) Malloc(size int32) (uint64, error) {
+	malloc, err := i.instance.Exports.GetFunction("malloc")
+	if err != nil {
+		return 0, fmt.Errorf("wasmer: module does not export malloc: %v", err)
+	}
+
+	addr, err := malloc(size)
+	if err != nil {
+		return 0, fmt.Errorf("wasmer: malloc(%d): %v", size, err)
+	}
+
+	return uint64(addr.(int32)), nil
+}
+
+func (i *instance) GetData() interface{} {
+	return i.data
+}
+
+func (i *instance) SetData(data interface{}) {
+	i.data = data
+}
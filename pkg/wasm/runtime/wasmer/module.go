@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasmer
+
+import (
+	"github.com/wasmerio/wasmer-go/wasmer"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// module wraps a compiled wasmer.Module plus the abi version it was compiled against, so every
+// instance created from it shares the same compiled code. Unlike the import object, which is
+// now built per instance (see newInstance), the compiled module itself has no per-instance
+// state and is safely shared.
+type module struct {
+	vm         *vm
+	module     *wasmer.Module
+	abiVersion string
+}
+
+func newModule(v *vm, wasmBytes []byte, abiVersion string) *module {
+	compiled, err := wasmer.NewModule(v.store, wasmBytes)
+	if err != nil {
+		return nil
+	}
+
+	if _, err := hostFunctionsFor(abiVersion); err != nil {
+		return nil
+	}
+
+	return &module{
+		vm:         v,
+		module:     compiled,
+		abiVersion: abiVersion,
+	}
+}
+
+func (m *module) NewInstance() types.WasmInstance {
+	i, err := newInstance(m)
+	if err != nil {
+		return nil
+	}
+	return i
+}
+
+func (m *module) Close() {}
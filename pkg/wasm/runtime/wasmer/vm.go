@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wasmer registers MOSN's default wasm engine, backed by the CGO wasmer-go
+// bindings around the Wasmer runtime. It is imported for side effect only:
+//
+//	import _ "mosn.io/mosn/pkg/wasm/runtime/wasmer"
+package wasmer
+
+import (
+	"github.com/wasmerio/wasmer-go/wasmer"
+
+	"mosn.io/mosn/pkg/wasm"
+	"mosn.io/mosn/pkg/types"
+)
+
+const EngineName = "wasmer"
+
+func init() {
+	wasm.RegisterWasmEngine(EngineName, NewWasmVM())
+}
+
+type vm struct {
+	engine *wasmer.Engine
+	store  *wasmer.Store
+}
+
+// NewWasmVM constructs a wasmer-backed types.WasmVM. It is exported mainly so tests can
+// construct one without going through the engine registry.
+func NewWasmVM() types.WasmVM {
+	return &vm{}
+}
+
+func (v *vm) Name() string {
+	return EngineName
+}
+
+func (v *vm) Init() error {
+	v.engine = wasmer.NewEngine()
+	v.store = wasmer.NewStore(v.engine)
+	return nil
+}
+
+func (v *vm) NewModule(wasmBytes []byte, abiVersion string) types.WasmModule {
+	if v.store == nil {
+		if err := v.Init(); err != nil {
+			return nil
+		}
+	}
+
+	return newModule(v, wasmBytes, abiVersion)
+}
+
+func (v *vm) Close() {}
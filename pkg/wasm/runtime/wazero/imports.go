@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wazero
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero"
+	wazeroapi "github.com/tetratelabs/wazero/api"
+
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_2_0"
+)
+
+// importArity mirrors runtime/wasmer's table: the i32 parameter count each proxy_* import
+// declares. Imports not listed default to 5, which covers every getter-style function.
+var importArity = map[string]int{
+	"proxy_http_call":             10,
+	"proxy_grpc_call":             12,
+	"proxy_grpc_stream":           10,
+	"proxy_grpc_send":             4,
+	"proxy_grpc_cancel":           1,
+	"proxy_grpc_close":            1,
+	"proxy_register_shared_queue": 3,
+	"proxy_enqueue_shared_queue":  3,
+	"proxy_dequeue_shared_queue":  3,
+}
+
+func arityOf(name string) int {
+	if n, ok := importArity[name]; ok {
+		return n
+	}
+	return 5
+}
+
+// registerHostModule instantiates the wasm engine's "env" host module, once per vm: wazero
+// keys host modules by name, so every module compiled against this vm - whatever ABI version
+// each is individually built against - must share the same "env" instance. It is built from
+// supersetHostFunctions rather than one ABI version's table, so whichever abiVersion gets
+// here first doesn't permanently lock "env" to that version's import names and leave a later
+// module needing the other version's names unable to instantiate.
+func registerHostModule(v *vm, abiVersion string) error {
+	if err := validateAbiVersion(abiVersion); err != nil {
+		return err
+	}
+
+	v.hostModuleMux.Lock()
+	defer v.hostModuleMux.Unlock()
+
+	if v.hostModuleRegistered {
+		return nil
+	}
+
+	builder := v.runtime.NewHostModuleBuilder("env")
+	for name, fn := range supersetHostFunctions() {
+		arity := arityOf(name)
+		params := make([]wazeroapi.ValueType, arity)
+		for i := range params {
+			params[i] = wazeroapi.ValueTypeI32
+		}
+
+		builder = builder.NewFunctionBuilder().
+			WithGoModuleFunction(wrapHostFunction(fn, arity), params, []wazeroapi.ValueType{wazeroapi.ValueTypeI32}).
+			Export(name)
+	}
+
+	if _, err := builder.Instantiate(v.ctx); err != nil {
+		return err
+	}
+
+	v.hostModuleRegistered = true
+	return nil
+}
+
+func validateAbiVersion(abiVersion string) error {
+	switch abiVersion {
+	case "", proxywasm_0_1_0.ProxyWasmABI_0_1_0, proxywasm_0_2_0.ProxyWasmABI_0_2_0:
+		return nil
+	default:
+		return fmt.Errorf("wazero: unknown abi version %s", abiVersion)
+	}
+}
+
+// supersetHostFunctions unions 0.1.0's and 0.2.0's import tables instead of picking one:
+// since "env" is only ever registered once per vm (see registerHostModule), it must be able
+// to satisfy either ABI's modules regardless of instantiation order. 0.2.0 renamed
+// proxy_get_header_map_value to proxy_get_map_value; both names are kept here, bound to the
+// same function, so a module built against either generation resolves it.
+func supersetHostFunctions() map[string]proxywasm_0_1_0.HostFunction {
+	fns := proxywasm_0_2_0.HostFunctions()
+	fns["proxy_get_header_map_value"] = fns["proxy_get_map_value"]
+	return fns
+}
+
+// wrapHostFunction adapts a proxywasm_0_1_0.HostFunction to wazero's low-level
+// api.GoModuleFunc calling convention (a stack of uint64s), resolving the calling instance
+// from mod - the specific api.Module wazero is invoking this closure on behalf of - via
+// instancesByModule, since the "env" host module these functions are registered on is shared
+// by every instance any vm ever instantiates.
+func wrapHostFunction(fn proxywasm_0_1_0.HostFunction, arity int) wazeroapi.GoModuleFunc {
+	return func(ctx context.Context, mod wazeroapi.Module, stack []uint64) {
+		v, ok := instancesByModule.Load(mod)
+		if !ok {
+			stack[0] = 0
+			return
+		}
+		caller := v.(*instance)
+
+		args := make([]int32, arity)
+		for i := 0; i < arity; i++ {
+			args[i] = int32(uint32(stack[i]))
+		}
+
+		stack[0] = uint64(uint32(fn(caller, args...)))
+	}
+}
+
+// wazeroImportingModuleConfig returns the module config used to instantiate a guest module
+// against m's vm; it exists as its own function so instance.go doesn't need to import
+// wazero directly for the zero-value config case.
+func wazeroImportingModuleConfig(m *module) wazero.ModuleConfig {
+	return wazero.NewModuleConfig()
+}
@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wazero
+
+import (
+	"fmt"
+	"sync"
+
+	wazeroapi "github.com/tetratelabs/wazero/api"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// instancesByModule maps a wazero api.Module (the concrete module instance wazero hands every
+// GoModuleFunc the instance that's calling it) back to the owning *instance, so host functions
+// wired up in imports.go can resolve the richer *instance wrapper (malloc, ABI data) they need
+// without any state shared between concurrently-running instances: registerHostModule builds
+// the "env" host module once per vm and shares it across every guest module and instance that
+// vm ever compiles, so the only thing distinguishing one instance's calls from another's is the
+// api.Module wazero itself passes into the closure.
+var instancesByModule sync.Map // wazeroapi.Module -> *instance
+
+// instance wraps a single wazero module instance. As with the wasmer backend, calls are
+// serialized through mux since linear memory is not safe for concurrent access.
+type instance struct {
+	mux     sync.Mutex
+	module  *module
+	api     wazeroapi.Module
+	data    interface{}
+	stopped bool
+}
+
+func newInstance(m *module) (*instance, error) {
+	cfg := wazeroImportingModuleConfig(m)
+
+	mod, err := m.vm.runtime.InstantiateModule(m.vm.ctx, m.compiled, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("wazero: instantiate: %v", err)
+	}
+
+	i := &instance{
+		module: m,
+		api:    mod,
+	}
+	instancesByModule.Store(mod, i)
+	return i, nil
+}
+
+func (i *instance) Acquire(abi types.ABI) bool {
+	i.mux.Lock()
+	if i.stopped {
+		i.mux.Unlock()
+		return false
+	}
+	return true
+}
+
+func (i *instance) Release() {
+	i.mux.Unlock()
+}
+
+func (i *instance) Start() error {
+	// wazero runs a module's start function (if any) during InstantiateModule.
+	return nil
+}
+
+func (i *instance) Stop() {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	i.stopped = true
+	instancesByModule.Delete(i.api)
+	_ = i.api.Close(i.module.vm.ctx)
+}
+
+func (i *instance) GetModule() types.WasmModule {
+	return i.module
+}
+
+func (i *instance) CallExportsFunc(name string, args ...int32) (int32, error) {
+	fn := i.api.ExportedFunction(name)
+	if fn == nil {
+		return 0, fmt.Errorf("wazero: no such export %s", name)
+	}
+
+	params := make([]uint64, len(args))
+	for idx, a := range args {
+		params[idx] = uint64(uint32(a))
+	}
+
+	ret, err := fn.Call(i.module.vm.ctx, params...)
+	if err != nil {
+		return 0, fmt.Errorf("wazero: call %s: %v", name, err)
+	}
+
+	if len(ret) == 0 {
+		return 0, nil
+	}
+	return int32(uint32(ret[0])), nil
+}
+
+func (i *instance) GetMemory(addr uint64, size uint64) ([]byte, error) {
+	data, ok := i.api.Memory().Read(uint32(addr), uint32(size))
+	if !ok {
+		return nil, fmt.Errorf("wazero: out of bounds memory access at %d, size %d", addr, size)
+	}
+
+	out := make([]byte, len(data))
+	copy(out, data)
+	return out, nil
+}
+
+func (i *instance) PutMemory(addr uint64, content []byte) error {
+	if !i.api.Memory().Write(uint32(addr), content) {
+		return fmt.Errorf("wazero: out of bounds memory write at %d, size %d", addr, len(content))
+	}
+	return nil
+}
+
+func (i *instance) Malloc(size int32) (uint64, error) {
+	malloc := i.api.ExportedFunction("malloc")
+	if malloc == nil {
+		return 0, fmt.Errorf("wazero: module does not export malloc")
+	}
+
+	ret, err := malloc.Call(i.module.vm.ctx, uint64(uint32(size)))
+	if err != nil {
+		return 0, fmt.Errorf("wazero: malloc(%d): %v", size, err)
+	}
+
+	return ret[0], nil
+}
+
+func (i *instance) GetData() interface{} {
+	return i.data
+}
+
+func (i *instance) SetData(data interface{}) {
+	i.data = data
+}
@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wazero
+
+import (
+	"github.com/tetratelabs/wazero"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// module wraps a wazero.CompiledModule plus the host module ("env") instantiated against
+// it, so every instance created from this module shares the same compiled code and host
+// function bindings.
+type module struct {
+	vm         *vm
+	compiled   wazero.CompiledModule
+	abiVersion string
+}
+
+func newModule(v *vm, wasmBytes []byte, abiVersion string) *module {
+	if err := registerHostModule(v, abiVersion); err != nil {
+		return nil
+	}
+
+	compiled, err := v.runtime.CompileModule(v.ctx, wasmBytes)
+	if err != nil {
+		return nil
+	}
+
+	return &module{
+		vm:         v,
+		compiled:   compiled,
+		abiVersion: abiVersion,
+	}
+}
+
+func (m *module) NewInstance() types.WasmInstance {
+	i, err := newInstance(m)
+	if err != nil {
+		return nil
+	}
+	return i
+}
+
+func (m *module) Close() {
+	_ = m.compiled.Close(m.vm.ctx)
+}
@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wazero registers a second wasm engine backed by the pure-Go wazero runtime. Unlike
+// pkg/wasm/runtime/wasmer it needs no CGO and no C toolchain, so it is the engine of choice
+// for static binaries and non-x86 targets. It is imported for side effect only:
+//
+//	import _ "mosn.io/mosn/pkg/wasm/runtime/wazero"
+package wazero
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/wasm"
+)
+
+const EngineName = "wazero"
+
+func init() {
+	wasm.RegisterWasmEngine(EngineName, NewWasmVM())
+}
+
+type vm struct {
+	ctx     context.Context
+	runtime wazero.Runtime
+
+	hostModuleMux        sync.Mutex
+	hostModuleRegistered bool // whether the "env" host module has been instantiated yet
+}
+
+// NewWasmVM constructs a wazero-backed types.WasmVM.
+func NewWasmVM() types.WasmVM {
+	return &vm{ctx: context.Background()}
+}
+
+func (v *vm) Name() string {
+	return EngineName
+}
+
+func (v *vm) Init() error {
+	v.runtime = wazero.NewRuntime(v.ctx)
+	return nil
+}
+
+func (v *vm) NewModule(wasmBytes []byte, abiVersion string) types.WasmModule {
+	if v.runtime == nil {
+		if err := v.Init(); err != nil {
+			return nil
+		}
+	}
+
+	return newModule(v, wasmBytes, abiVersion)
+}
+
+func (v *vm) Close() {
+	if v.runtime != nil {
+		_ = v.runtime.Close(v.ctx)
+	}
+}
@@ -0,0 +1,182 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+)
+
+// sharedDataEntry is the value stored under one (vm_id, key) pair, plus the cas counter
+// proxy_set_shared_data's optimistic-concurrency check compares against.
+type sharedDataEntry struct {
+	mu    sync.Mutex
+	value []byte
+	cas   uint32
+}
+
+// sharedDataStore is WasmPluginWrapper's proxywasm_0_1_0.SharedDataStore implementation: a
+// sync.Map keyed by "vm_id\x00key" to an *sharedDataEntry, so unrelated keys never contend on
+// the same lock.
+type sharedDataStore struct {
+	entries sync.Map // string (vm_id + "\x00" + key) -> *sharedDataEntry
+}
+
+func newSharedDataStore() *sharedDataStore {
+	return &sharedDataStore{}
+}
+
+func sharedDataKey(vmID, key string) string {
+	return vmID + "\x00" + key
+}
+
+func (s *sharedDataStore) Get(vmID string, key string) ([]byte, uint32, bool) {
+	v, ok := s.entries.Load(sharedDataKey(vmID, key))
+	if !ok {
+		return nil, 0, false
+	}
+
+	e := v.(*sharedDataEntry)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]byte(nil), e.value...), e.cas, true
+}
+
+func (s *sharedDataStore) Set(vmID string, key string, value []byte, cas uint32) error {
+	v, _ := s.entries.LoadOrStore(sharedDataKey(vmID, key), &sharedDataEntry{})
+	e := v.(*sharedDataEntry)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if cas != 0 && e.cas != 0 && cas != e.cas {
+		return proxywasm_0_1_0.ErrCasMismatch
+	}
+
+	e.value = append([]byte(nil), value...)
+	e.cas++
+	return nil
+}
+
+// sharedQueueEntry is one registered queue: a FIFO buffer plus the instance/rootContextID a
+// producer's Enqueue re-enters via ProxyOnQueueReady.
+type sharedQueueEntry struct {
+	mu       sync.Mutex
+	data     [][]byte
+	consumer types.WasmInstance
+	rootCtx  int32
+}
+
+// sharedQueue is WasmPluginWrapper's proxywasm_0_1_0.SharedQueue implementation.
+type sharedQueue struct {
+	mu     sync.Mutex
+	byName map[string]uint32 // "vm_id\x00name" -> queueID
+	queues map[uint32]*sharedQueueEntry
+	nextID uint32
+}
+
+func newSharedQueue() *sharedQueue {
+	return &sharedQueue{
+		byName: make(map[string]uint32),
+		queues: make(map[uint32]*sharedQueueEntry),
+	}
+}
+
+func (q *sharedQueue) Register(vmID string, queueName string, instance types.WasmInstance, rootContextID int32) (uint32, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := sharedDataKey(vmID, queueName)
+	queueID, ok := q.byName[key]
+	if !ok {
+		queueID = atomic.AddUint32(&q.nextID, 1)
+		q.byName[key] = queueID
+		q.queues[queueID] = &sharedQueueEntry{}
+	}
+
+	entry := q.queues[queueID]
+	entry.mu.Lock()
+	entry.consumer = instance
+	entry.rootCtx = rootContextID
+	entry.mu.Unlock()
+
+	return queueID, nil
+}
+
+func (q *sharedQueue) Resolve(vmID string, queueName string) (uint32, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queueID, ok := q.byName[sharedDataKey(vmID, queueName)]
+	return queueID, ok
+}
+
+func (q *sharedQueue) Enqueue(queueID uint32, data []byte, producer types.WasmInstance) error {
+	q.mu.Lock()
+	entry, ok := q.queues[queueID]
+	q.mu.Unlock()
+	if !ok {
+		return proxywasm_0_1_0.ErrQueueNotFound
+	}
+
+	entry.mu.Lock()
+	entry.data = append(entry.data, append([]byte(nil), data...))
+	consumer, rootCtx := entry.consumer, entry.rootCtx
+	entry.mu.Unlock()
+
+	if consumer != nil {
+		// producer's lock is already held by whoever called Acquire before reaching this
+		// host function, for the duration of the plugin call that triggered this Enqueue.
+		// When the consumer is a different instance, that lock says nothing about the
+		// consumer's own, so it must be acquired here before re-entering it - same
+		// contract as every other call site. When consumer == producer, re-acquiring
+		// would deadlock on the very lock the caller already holds, and is also
+		// unnecessary: that lock already covers the reentrant call.
+		if consumer == producer {
+			_, _ = consumer.CallExportsFunc("proxy_on_queue_ready", rootCtx, int32(queueID))
+		} else if consumer.Acquire(nil) {
+			_, _ = consumer.CallExportsFunc("proxy_on_queue_ready", rootCtx, int32(queueID))
+			consumer.Release()
+		}
+	}
+
+	return nil
+}
+
+func (q *sharedQueue) Dequeue(queueID uint32) ([]byte, bool) {
+	q.mu.Lock()
+	entry, ok := q.queues[queueID]
+	q.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if len(entry.data) == 0 {
+		return nil, false
+	}
+
+	data := entry.data[0]
+	entry.data = entry.data[1:]
+	return data, true
+}
@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// fakeQueueInstance is a minimal types.WasmInstance that only tracks Acquire/Release and
+// CallExportsFunc, enough to exercise sharedQueue.Enqueue's locking without a real wasm
+// engine.
+type fakeQueueInstance struct {
+	mux      sync.Mutex
+	held     bool
+	recorded []string
+}
+
+func (f *fakeQueueInstance) Acquire(abi types.ABI) bool {
+	if !f.mux.TryLock() {
+		return false
+	}
+	f.held = true
+	return true
+}
+
+func (f *fakeQueueInstance) Release() {
+	f.held = false
+	f.mux.Unlock()
+}
+
+func (f *fakeQueueInstance) Start() error                { return nil }
+func (f *fakeQueueInstance) Stop()                       {}
+func (f *fakeQueueInstance) GetModule() types.WasmModule { return nil }
+
+func (f *fakeQueueInstance) CallExportsFunc(name string, args ...int32) (int32, error) {
+	f.recorded = append(f.recorded, name)
+	return 0, nil
+}
+
+func (f *fakeQueueInstance) GetMemory(addr uint64, size uint64) ([]byte, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+func (f *fakeQueueInstance) PutMemory(addr uint64, content []byte) error {
+	return fmt.Errorf("not implemented")
+}
+func (f *fakeQueueInstance) Malloc(size int32) (uint64, error) {
+	return 0, fmt.Errorf("not implemented")
+}
+func (f *fakeQueueInstance) GetData() interface{}     { return nil }
+func (f *fakeQueueInstance) SetData(data interface{}) {}
+
+// TestSharedQueueEnqueueAcquiresDistinctConsumer verifies Enqueue locks the consumer instance
+// before re-entering it when the consumer differs from the producer, matching the Acquire/
+// Release contract every other call site follows.
+func TestSharedQueueEnqueueAcquiresDistinctConsumer(t *testing.T) {
+	q := newSharedQueue()
+	consumer := &fakeQueueInstance{}
+	producer := &fakeQueueInstance{}
+
+	queueID, err := q.Register("vm1", "work_queue", consumer, 7)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := q.Enqueue(queueID, []byte("job"), producer); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if len(consumer.recorded) != 1 || consumer.recorded[0] != "proxy_on_queue_ready" {
+		t.Fatalf("consumer CallExportsFunc = %v, want one proxy_on_queue_ready call", consumer.recorded)
+	}
+	if consumer.held {
+		t.Fatalf("consumer left held after Enqueue returned")
+	}
+}
+
+// TestSharedQueueEnqueueSkipsAcquireForSelfConsumer verifies Enqueue does not try to
+// re-acquire the consumer's lock when the producer and consumer are the same instance - the
+// common case with a single pooled instance producing into a queue it also consumes - since
+// the caller already holds that lock and Acquire is not reentrant.
+func TestSharedQueueEnqueueSkipsAcquireForSelfConsumer(t *testing.T) {
+	q := newSharedQueue()
+	self := &fakeQueueInstance{}
+
+	queueID, err := q.Register("vm1", "work_queue", self, 7)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if !self.Acquire(nil) {
+		t.Fatalf("Acquire: want true")
+	}
+	defer self.Release()
+
+	done := make(chan error, 1)
+	go func() { done <- q.Enqueue(queueID, []byte("job"), self) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Enqueue deadlocked trying to re-acquire its own caller's lock")
+	}
+
+	if len(self.recorded) != 1 || self.recorded[0] != "proxy_on_queue_ready" {
+		t.Fatalf("self CallExportsFunc = %v, want one proxy_on_queue_ready call", self.recorded)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package remote exercises pkg/wasm/runtime/remote end-to-end: a real sidecar (backed by
+// wasmer, exactly like the reference binary under pkg/wasm/runtime/remote/sidecar) serving
+// the Exchange RPC over a loopback TCP listener, and a plugin loaded against it through the
+// same wasm.Manager API pkg/wasm/test's in-process tests use. It lives in its own package,
+// rather than alongside pkg/wasm/test/wasm_test.go's forEachEngine-driven tests, because
+// importing pkg/wasm/runtime/remote registers the "remote" engine process-wide - doing that
+// in the same test binary as forEachEngine would make every existing in-process test also
+// try (and fail) to dial a "remote" engine with no address configured.
+package remote
+
+import (
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/wasm"
+	"mosn.io/mosn/pkg/wasm/abi"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+	"mosn.io/mosn/pkg/wasm/runtime/remote"
+	"mosn.io/mosn/pkg/wasm/runtime/wasmer"
+)
+
+// startSidecar serves a real wasmer-backed Sidecar on a loopback TCP port and returns its
+// address, tearing the server down when t completes.
+func startSidecar(t testing.TB) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	server := grpc.NewServer()
+	remote.NewSidecar(wasmer.NewWasmVM()).Register(server)
+
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+// testRemoteCommon mirrors pkg/wasm/test's testCommon, but against a plugin loaded through
+// the remote engine instead of in-process - same sequence of Exports calls against the same
+// fixtures, just with every call round-tripping over the Exchange gRPC stream to the sidecar
+// startSidecar spun up.
+func testRemoteCommon(t *testing.T, pluginName, path, abiVersion string) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager := wasm.GetWasmManager()
+	err := manager.AddOrUpdateWasm(v2.WasmPluginConfig{
+		PluginName: pluginName,
+		VmConfig: &v2.WasmVmConfig{
+			Engine:     remote.EngineName,
+			Path:       path,
+			AbiVersion: abiVersion,
+			Address:    startSidecar(t),
+		},
+		InstanceNum: 1,
+	})
+	if err != nil {
+		t.Fatalf("AddOrUpdateWasm: %v", err)
+	}
+
+	plugin := manager.GetWasmPluginWrapperByName(pluginName).GetPlugin()
+	instance := plugin.GetInstance()
+
+	instanceABI := abi.GetABI(instance, abiVersion)
+	instanceABI.SetImports(&mockInstanceCallback{vmID: pluginName})
+	exports := instanceABI.GetExports().(proxywasm_0_1_0.Exports)
+
+	instance.Acquire(instanceABI)
+	defer instance.Release()
+
+	rootContextID, contextID := int32(100), int32(101)
+
+	if err := exports.ProxyOnContextCreate(rootContextID, 0); err != nil {
+		t.Errorf("ProxyOnContextCreate(root): %v", err)
+	}
+	if _, err := exports.ProxyOnConfigure(rootContextID, 0); err != nil {
+		t.Errorf("ProxyOnConfigure: %v", err)
+	}
+	if _, err := exports.ProxyOnVmStart(rootContextID, 0); err != nil {
+		t.Errorf("ProxyOnVmStart: %v", err)
+	}
+	if err := exports.ProxyOnContextCreate(contextID, rootContextID); err != nil {
+		t.Errorf("ProxyOnContextCreate(context): %v", err)
+	}
+	if _, err := exports.ProxyOnRequestHeaders(contextID, 0, 1); err != nil {
+		t.Errorf("ProxyOnRequestHeaders: %v", err)
+	}
+	if _, err := exports.ProxyOnDone(contextID); err != nil {
+		t.Errorf("ProxyOnDone: %v", err)
+	}
+}
+
+// TestWasmHttpRemote is TestWasmHttp (pkg/wasm/test/wasm_test.go) driven over the remote
+// transport instead of in-process, per the request to exercise both with the same sequence.
+func TestWasmHttpRemote(t *testing.T) {
+	testRemoteCommon(t, "testWasmHttpFull_remote", "../data/httpFull.wasm", proxywasm_0_1_0.ProxyWasmABI_0_1_0)
+}
+
+// TestWasmHttpRemoteConcurrent drives several pooled instances against the same sidecar
+// concurrently, one goroutine per instance. All of them share the one gRPC stream a module's
+// dispatcher owns (module.go), so this is what exercises dispatcher.send actually serializing
+// Send calls instead of handing grpc-go two goroutines writing the stream at once.
+func TestWasmHttpRemoteConcurrent(t *testing.T) {
+	const instanceNum = 8
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager := wasm.GetWasmManager()
+	pluginName := "testWasmHttpFull_remote_concurrent"
+	err := manager.AddOrUpdateWasm(v2.WasmPluginConfig{
+		PluginName: pluginName,
+		VmConfig: &v2.WasmVmConfig{
+			Engine:     remote.EngineName,
+			Path:       "../data/httpFull.wasm",
+			AbiVersion: proxywasm_0_1_0.ProxyWasmABI_0_1_0,
+			Address:    startSidecar(t),
+		},
+		InstanceNum: instanceNum,
+	})
+	if err != nil {
+		t.Fatalf("AddOrUpdateWasm: %v", err)
+	}
+
+	plugin := manager.GetWasmPluginWrapperByName(pluginName).GetPlugin()
+
+	var wg sync.WaitGroup
+	for n := 0; n < instanceNum; n++ {
+		instance := plugin.GetInstance()
+
+		instanceABI := abi.GetABI(instance, proxywasm_0_1_0.ProxyWasmABI_0_1_0)
+		instanceABI.SetImports(&mockInstanceCallback{vmID: pluginName})
+		exports := instanceABI.GetExports().(proxywasm_0_1_0.Exports)
+
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+
+			instance.Acquire(instanceABI)
+			defer instance.Release()
+
+			rootContextID, contextID := int32(100+n*2), int32(101+n*2)
+			if err := exports.ProxyOnContextCreate(rootContextID, 0); err != nil {
+				t.Errorf("ProxyOnContextCreate(root): %v", err)
+			}
+			if _, err := exports.ProxyOnConfigure(rootContextID, 0); err != nil {
+				t.Errorf("ProxyOnConfigure: %v", err)
+			}
+			if _, err := exports.ProxyOnVmStart(rootContextID, 0); err != nil {
+				t.Errorf("ProxyOnVmStart: %v", err)
+			}
+			if err := exports.ProxyOnContextCreate(contextID, rootContextID); err != nil {
+				t.Errorf("ProxyOnContextCreate(context): %v", err)
+			}
+			if _, err := exports.ProxyOnRequestHeaders(contextID, 0, 1); err != nil {
+				t.Errorf("ProxyOnRequestHeaders: %v", err)
+			}
+			if _, err := exports.ProxyOnDone(contextID); err != nil {
+				t.Errorf("ProxyOnDone: %v", err)
+			}
+		}(n)
+	}
+	wg.Wait()
+}
+
+// mockInstanceCallback is a minimal ImportsHandler for the remote tests: it doesn't need
+// pkg/wasm/test's gomock-backed header map, since the remote transport's own plumbing (not
+// the header contents) is what's under test here.
+type mockInstanceCallback struct {
+	proxywasm_0_1_0.DefaultInstanceCallback
+	vmID string
+}
+
+func (m *mockInstanceCallback) GetVmID() string { return m.vmID }
+
+func benchRemoteCommon(b *testing.B, pluginName, path string) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	manager := wasm.GetWasmManager()
+	err := manager.AddOrUpdateWasm(v2.WasmPluginConfig{
+		PluginName: pluginName,
+		VmConfig: &v2.WasmVmConfig{
+			Engine:  remote.EngineName,
+			Path:    path,
+			Address: startSidecar(b),
+		},
+		InstanceNum: runtime.NumCPU(),
+	})
+	if err != nil {
+		b.Fatalf("AddOrUpdateWasm: %v", err)
+	}
+
+	plugin := manager.GetWasmPluginWrapperByName(pluginName).GetPlugin()
+	instance := plugin.GetInstance()
+
+	instanceABI := abi.GetABI(instance, proxywasm_0_1_0.ProxyWasmABI_0_1_0)
+	instanceABI.SetImports(&mockInstanceCallback{vmID: pluginName})
+	exports := instanceABI.GetExports().(proxywasm_0_1_0.Exports)
+
+	instance.Acquire(instanceABI)
+	rootContextID := 100
+	_ = exports.ProxyOnContextCreate(int32(rootContextID), 0)
+	_, _ = exports.ProxyOnConfigure(int32(rootContextID), 0)
+	_, _ = exports.ProxyOnVmStart(int32(rootContextID), 0)
+	instance.Release()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		instance.Acquire(instanceABI)
+
+		contextID := 101 + i
+		_ = exports.ProxyOnContextCreate(int32(contextID), int32(rootContextID))
+		_, _ = exports.ProxyOnRequestHeaders(int32(contextID), 0, 1)
+		_, _ = exports.ProxyOnDone(int32(contextID))
+
+		instance.Release()
+	}
+
+	plugin.ReleaseInstance(instance)
+}
+
+// BenchmarkWasmProxyHttpRemote is BenchmarkWasmProxyHttp driven over the remote transport.
+func BenchmarkWasmProxyHttpRemote(b *testing.B) {
+	benchRemoteCommon(b, "benchPluginHttp_remote", "../data/httpFull.wasm")
+}
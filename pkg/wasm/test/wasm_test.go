@@ -18,8 +18,10 @@
 package test
 
 import (
+	"fmt"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"mosn.io/api"
@@ -29,14 +31,20 @@ import (
 	"mosn.io/mosn/pkg/wasm"
 	"mosn.io/mosn/pkg/wasm/abi"
 	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_2_0"
 	_ "mosn.io/mosn/pkg/wasm/runtime/wasmer"
+	_ "mosn.io/mosn/pkg/wasm/runtime/wazero"
 	"mosn.io/pkg/buffer"
 )
 
+// mockInstanceCallback embeds the 0.2.0 defaults, which in turn embed the 0.1.0 defaults,
+// so a single mock satisfies both proxywasm_0_1_0.ImportsHandler and
+// proxywasm_0_2_0.ImportsHandler and testCommon can drive either ABI with it.
 type mockInstanceCallback struct {
-	proxywasm_0_1_0.DefaultInstanceCallback
+	proxywasm_0_2_0.DefaultInstanceCallback
 
 	ctrl           *gomock.Controller
+	vmID           string
 	requestHeader  api.HeaderMap
 	requestBody    buffer.IoBuffer
 	responseHeader api.HeaderMap
@@ -45,7 +53,7 @@ type mockInstanceCallback struct {
 	pluginConfig   buffer.IoBuffer
 }
 
-func newMockInstanceCallback(ctrl *gomock.Controller) *mockInstanceCallback {
+func newMockInstanceCallback(ctrl *gomock.Controller, vmID string) *mockInstanceCallback {
 	var m = map[string]string{
 		"requestHeaderKey1": "requestHeaderValue1",
 		"requestHeaderKey2": "requestHeaderValue2",
@@ -76,6 +84,7 @@ func newMockInstanceCallback(ctrl *gomock.Controller) *mockInstanceCallback {
 
 	return &mockInstanceCallback{
 		ctrl:           ctrl,
+		vmID:           vmID,
 		requestHeader:  h,
 		requestBody:    buffer.NewIoBufferString("request body"),
 		responseHeader: nil,
@@ -89,6 +98,10 @@ func (i *mockInstanceCallback) GetRootContextID() int32 {
 	return 0
 }
 
+func (i *mockInstanceCallback) GetVmID() string {
+	return i.vmID
+}
+
 func (i *mockInstanceCallback) GetVmConfig() buffer.IoBuffer {
 	return i.vmConfig
 }
@@ -140,7 +153,7 @@ func (i *mockInstanceCallback) Log(level log.Level, msg string) {
 	logFunc(msg)
 }
 
-func testCommon(t *testing.T, pluginName string, engine string, path string) {
+func testCommon(t *testing.T, pluginName string, engine string, path string, abiVersion string) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -148,8 +161,9 @@ func testCommon(t *testing.T, pluginName string, engine string, path string) {
 	_ = manager.AddOrUpdateWasm(v2.WasmPluginConfig{
 		PluginName: pluginName,
 		VmConfig: &v2.WasmVmConfig{
-			Engine: engine,
-			Path:   path,
+			Engine:     engine,
+			Path:       path,
+			AbiVersion: abiVersion,
 		},
 		InstanceNum: 1,
 	})
@@ -157,9 +171,9 @@ func testCommon(t *testing.T, pluginName string, engine string, path string) {
 	plugin := manager.GetWasmPluginWrapperByName(pluginName).GetPlugin()
 	instance := plugin.GetInstance()
 
-	abi := abi.GetABI(instance, proxywasm_0_1_0.ProxyWasmABI_0_1_0)
+	abi := abi.GetABI(instance, abiVersion)
 
-	cb := newMockInstanceCallback(ctrl)
+	cb := newMockInstanceCallback(ctrl, pluginName)
 	abi.SetImports(cb)
 
 	exports := abi.GetExports().(proxywasm_0_1_0.Exports)
@@ -197,12 +211,115 @@ func testCommon(t *testing.T, pluginName string, engine string, path string) {
 	}
 }
 
+// forEachEngine runs f once per registered wasm engine, so every fixture below exercises
+// both the wasmer and wazero backends instead of hardcoding one.
+func forEachEngine(t *testing.T, f func(t *testing.T, engine string)) {
+	engines := wasm.RegisteredEngines()
+	if len(engines) == 0 {
+		t.Fatal("no wasm engines registered")
+	}
+
+	for _, engine := range engines {
+		engine := engine
+		t.Run(engine, func(t *testing.T) {
+			f(t, engine)
+		})
+	}
+}
+
 func TestWasmProxyLog(t *testing.T) {
-	testCommon(t, "testWasmProxyLog", "wasmer", "./data/log.wasm")
+	forEachEngine(t, func(t *testing.T, engine string) {
+		testCommon(t, fmt.Sprintf("testWasmProxyLog_%s", engine), engine, "./data/log.wasm", proxywasm_0_1_0.ProxyWasmABI_0_1_0)
+	})
 }
 
 func TestWasmHttp(t *testing.T) {
-	testCommon(t, "testWasmHttpFull", "wasmer", "./data/httpFull.wasm")
+	forEachEngine(t, func(t *testing.T, engine string) {
+		testCommon(t, fmt.Sprintf("testWasmHttpFull_%s", engine), engine, "./data/httpFull.wasm", proxywasm_0_1_0.ProxyWasmABI_0_1_0)
+	})
+}
+
+func TestWasmHttpAbi020(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, engine string) {
+		testCommon(t, fmt.Sprintf("testWasmHttpFullAbi020_%s", engine), engine, "./data/httpFull020.wasm", proxywasm_0_2_0.ProxyWasmABI_0_2_0)
+	})
+}
+
+// TestWasmReload reloads a plugin while a context is still outstanding on the generation
+// being replaced, and asserts the old generation's instances aren't released until that
+// context finishes (ProxyOnDone), rather than being torn down the moment the new one goes
+// Ready.
+func TestWasmReload(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, engine string) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		pluginName := fmt.Sprintf("testWasmReload_%s", engine)
+		manager := wasm.GetWasmManager()
+
+		config := v2.WasmPluginConfig{
+			PluginName: pluginName,
+			VmConfig: &v2.WasmVmConfig{
+				Engine: engine,
+				Path:   "./data/httpFull.wasm",
+			},
+			InstanceNum:    1,
+			DrainTimeoutMs: 2000,
+		}
+
+		if err := manager.AddOrUpdateWasm(config); err != nil {
+			t.Fatalf("initial AddOrUpdateWasm: %v", err)
+		}
+
+		wrapper := manager.GetWasmPluginWrapperByName(pluginName)
+		plugin := wrapper.GetPlugin()
+		instance := plugin.GetInstance()
+
+		pluginABI := abi.GetABI(instance, proxywasm_0_1_0.ProxyWasmABI_0_1_0)
+		pluginABI.SetImports(newMockInstanceCallback(ctrl, pluginName))
+		exports := pluginABI.GetExports().(proxywasm_0_1_0.Exports)
+
+		rootContextID, contextID := int32(100), int32(101)
+
+		instance.Acquire(pluginABI)
+		if err := exports.ProxyOnContextCreate(contextID, rootContextID); err != nil {
+			t.Fatalf("ProxyOnContextCreate: %v", err)
+		}
+		plugin.ContextCreated()
+		if _, err := exports.ProxyOnRequestHeaders(contextID, 0, 1); err != nil {
+			t.Fatalf("ProxyOnRequestHeaders: %v", err)
+		}
+		instance.Release()
+
+		// Reload while contextID is still outstanding on the plugin we just acquired: the old
+		// generation must keep this instance alive until ProxyOnDone runs below, even though
+		// wrapper.current now points at the new generation.
+		if err := manager.AddOrUpdateWasm(config); err != nil {
+			t.Fatalf("reload AddOrUpdateWasm: %v", err)
+		}
+
+		if state := wrapper.State(); state != wasm.PluginStateReady {
+			t.Fatalf("new generation state = %v, want Ready", state)
+		}
+		if active := plugin.ActiveContexts(); active != 1 {
+			t.Fatalf("old generation ActiveContexts = %d, want 1 before ProxyOnDone", active)
+		}
+
+		instance.Acquire(pluginABI)
+		if _, err := exports.ProxyOnDone(contextID); err != nil {
+			t.Fatalf("ProxyOnDone: %v", err)
+		}
+		plugin.ContextDone()
+		instance.Release()
+
+		deadline := time.Now().Add(3 * time.Second)
+		for plugin.ActiveContexts() != 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if active := plugin.ActiveContexts(); active != 0 {
+			t.Fatalf("old generation leaked %d active context(s) after drain", active)
+		}
+	})
 }
 
 func benchCommon(b *testing.B, pluginName string, engine string, path string) {
@@ -224,7 +341,7 @@ func benchCommon(b *testing.B, pluginName string, engine string, path string) {
 
 	abi := abi.GetABI(instance, proxywasm_0_1_0.ProxyWasmABI_0_1_0)
 
-	cb := newMockInstanceCallback(ctrl)
+	cb := newMockInstanceCallback(ctrl, pluginName)
 	abi.SetImports(cb)
 
 	exports := abi.GetExports().(proxywasm_0_1_0.Exports)
@@ -252,10 +369,23 @@ func benchCommon(b *testing.B, pluginName string, engine string, path string) {
 	plugin.ReleaseInstance(instance)
 }
 
+func forEachEngineBench(b *testing.B, f func(b *testing.B, engine string)) {
+	for _, engine := range wasm.RegisteredEngines() {
+		engine := engine
+		b.Run(engine, func(b *testing.B) {
+			f(b, engine)
+		})
+	}
+}
+
 func BenchmarkWasmEmptyCall(b *testing.B) {
-	benchCommon(b, "benchPluginEmptyCall", "wasmer", "./data/emptyCall.wasm")
+	forEachEngineBench(b, func(b *testing.B, engine string) {
+		benchCommon(b, fmt.Sprintf("benchPluginEmptyCall_%s", engine), engine, "./data/emptyCall.wasm")
+	})
 }
 
 func BenchmarkWasmProxyHttp(b *testing.B) {
-	benchCommon(b, "benchPluginProxyHttp", "wasmer", "./data/httpFull.wasm")
+	forEachEngineBench(b, func(b *testing.B, engine string) {
+		benchCommon(b, fmt.Sprintf("benchPluginProxyHttp_%s", engine), engine, "./data/httpFull.wasm")
+	})
 }
@@ -0,0 +1,383 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wasm is MOSN's wasm plugin manager: it owns the lifecycle of configured wasm
+// plugins (compiling modules, pooling instances, and swapping plugins on config update) and
+// is the entry point filters use to look up a plugin by name.
+package wasm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/wasm/abi"
+	"mosn.io/mosn/pkg/wasm/abi/proxywasm_0_1_0"
+)
+
+var (
+	engineMux sync.RWMutex
+	engines   = make(map[string]types.WasmVM)
+)
+
+// RegisterWasmEngine registers a wasm engine implementation under name so it can be
+// selected via WasmVmConfig.Engine, e.g. "wasmer" or "wazero". Engine packages call this
+// from their init().
+func RegisterWasmEngine(name string, vm types.WasmVM) {
+	engineMux.Lock()
+	defer engineMux.Unlock()
+
+	engines[name] = vm
+}
+
+func getWasmEngine(name string) (types.WasmVM, bool) {
+	engineMux.RLock()
+	defer engineMux.RUnlock()
+
+	vm, ok := engines[name]
+	return vm, ok
+}
+
+// RegisteredEngines returns the names of every wasm engine registered so far, e.g.
+// ["wasmer", "wazero"]. Tests use this to run the same fixture against every available
+// backend instead of hardcoding one.
+func RegisteredEngines() []string {
+	engineMux.RLock()
+	defer engineMux.RUnlock()
+
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	return names
+}
+
+// WasmPlugin is a compiled module plus a pool of ready-to-use instances.
+type WasmPlugin interface {
+	// GetInstance acquires an instance from the pool, creating it lazily on first use.
+	GetInstance() types.WasmInstance
+
+	// ReleaseInstance returns instance to the pool.
+	ReleaseInstance(instance types.WasmInstance)
+
+	// InstanceNum is the configured pool size.
+	InstanceNum() int
+
+	// ActiveContexts returns the number of contexts created via ContextCreated but not yet
+	// matched by ContextDone, across every instance in this plugin. WasmPluginWrapper polls
+	// it while draining a superseded generation.
+	//
+	// Nothing in this package calls ContextCreated/ContextDone for a real per-request
+	// context: the caller that should - MOSN's wasm stream filter, driving
+	// Exports.ProxyOnContextCreate/ProxyOnDone/ProxyOnDelete per request - lives outside
+	// this package and isn't present here. Until that caller is wired up, ActiveContexts
+	// always reports 0, so startDraining's poll sees "no contexts outstanding" on its very
+	// first tick and releases the draining generation immediately rather than waiting for
+	// requests that are actually still in flight.
+	ActiveContexts() int32
+
+	// ContextCreated and ContextDone bracket one contextID's lifetime on this plugin:
+	// callers invoke them alongside Exports.ProxyOnContextCreate and
+	// Exports.ProxyOnDone/ProxyOnDelete so a reload can tell when it is safe to stop this
+	// plugin. See the ActiveContexts doc above: no such caller exists in this package yet.
+	ContextCreated()
+	ContextDone()
+
+	// Close stops every pooled instance and releases the compiled module. It is called once
+	// this plugin has finished draining.
+	Close()
+}
+
+type wasmPlugin struct {
+	mux       sync.Mutex
+	module    types.WasmModule
+	instances []types.WasmInstance
+	next      int
+
+	active int32 // atomic: contexts created but not yet done, across every instance
+}
+
+func (p *wasmPlugin) GetInstance() types.WasmInstance {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	instance := p.instances[p.next]
+	p.next = (p.next + 1) % len(p.instances)
+	return instance
+}
+
+func (p *wasmPlugin) ReleaseInstance(instance types.WasmInstance) {
+	// instances are long-lived and pooled by round-robin, nothing to release per-call.
+}
+
+func (p *wasmPlugin) InstanceNum() int {
+	return len(p.instances)
+}
+
+func (p *wasmPlugin) ActiveContexts() int32 {
+	return atomic.LoadInt32(&p.active)
+}
+
+func (p *wasmPlugin) ContextCreated() {
+	atomic.AddInt32(&p.active, 1)
+}
+
+func (p *wasmPlugin) ContextDone() {
+	atomic.AddInt32(&p.active, -1)
+}
+
+func (p *wasmPlugin) Close() {
+	for _, instance := range p.instances {
+		instance.Stop()
+		proxywasm_0_1_0.ReleaseCallouts(instance)
+	}
+	p.module.Close()
+}
+
+// WasmPluginWrapper is the unit tracked by WasmManager: it holds the plugin generation(s) for
+// a configured plugin name and is what AddOrUpdateWasm reloads on reconfiguration. See
+// lifecycle.go for the Loading/Ready/Draining/Stopped state machine.
+type WasmPluginWrapper struct {
+	mux    sync.RWMutex
+	config v2.WasmPluginConfig
+
+	current  *pluginGeneration // serving traffic
+	previous *pluginGeneration // being drained after a reload, nil once fully released
+
+	drainTimeout time.Duration
+
+	handlersMux sync.RWMutex
+	handlers    []PluginHandler
+
+	// sharedData and sharedQueue back proxy_get_shared_data/proxy_set_shared_data and the
+	// proxy_*_shared_queue imports for every instance pooled under this wrapper, across
+	// generations, so a reload doesn't drop state plugins handed off through them.
+	sharedData  *sharedDataStore
+	sharedQueue *sharedQueue
+}
+
+// GetSharedDataStore returns the key/value store shared by every instance of this plugin, or
+// nil if none has been created yet.
+func (w *WasmPluginWrapper) GetSharedDataStore() proxywasm_0_1_0.SharedDataStore {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	if w.sharedData == nil {
+		return nil
+	}
+	return w.sharedData
+}
+
+// GetSharedQueue returns the message queue shared by every instance of this plugin, or nil if
+// none has been created yet.
+func (w *WasmPluginWrapper) GetSharedQueue() proxywasm_0_1_0.SharedQueue {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	if w.sharedQueue == nil {
+		return nil
+	}
+	return w.sharedQueue
+}
+
+// GetPlugin returns the plugin generation currently serving traffic, or nil if none has
+// loaded successfully yet.
+func (w *WasmPluginWrapper) GetPlugin() WasmPlugin {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	if w.current == nil {
+		return nil
+	}
+	return w.current.plugin
+}
+
+// GetConfig returns the config this wrapper was last updated with.
+func (w *WasmPluginWrapper) GetConfig() v2.WasmPluginConfig {
+	w.mux.RLock()
+	defer w.mux.RUnlock()
+
+	return w.config
+}
+
+// WasmManager tracks every configured wasm plugin by name.
+type WasmManager struct {
+	mux     sync.RWMutex
+	plugins map[string]*WasmPluginWrapper
+}
+
+var (
+	managerOnce sync.Once
+	manager     *WasmManager
+)
+
+// GetWasmManager returns the process-wide wasm plugin manager.
+func GetWasmManager() *WasmManager {
+	managerOnce.Do(func() {
+		manager = &WasmManager{
+			plugins: make(map[string]*WasmPluginWrapper),
+		}
+	})
+	return manager
+}
+
+// AddOrUpdateWasm compiles config's module, warms it up, and installs it as the generation
+// serving config.PluginName. If a generation is already serving that name, it is demoted to
+// PluginStateDraining and released once its outstanding contexts finish (or drainTimeout
+// elapses), rather than being torn down immediately.
+func (m *WasmManager) AddOrUpdateWasm(config v2.WasmPluginConfig) error {
+	if config.VmConfig == nil {
+		return fmt.Errorf("wasm plugin %s: missing vm_config", config.PluginName)
+	}
+
+	vm, ok := getWasmEngine(config.VmConfig.Engine)
+	if !ok {
+		return fmt.Errorf("wasm plugin %s: unknown engine %s", config.PluginName, config.VmConfig.Engine)
+	}
+
+	code := config.VmConfig.Code
+	if code == nil {
+		var err error
+		code, err = ioutil.ReadFile(config.VmConfig.Path)
+		if err != nil {
+			return fmt.Errorf("wasm plugin %s: read wasm binary: %v", config.PluginName, err)
+		}
+	}
+
+	abiVersion := config.VmConfig.AbiVersion
+	if abiVersion == "" {
+		abiVersion = proxywasm_0_1_0.ProxyWasmABI_0_1_0
+	}
+
+	m.mux.Lock()
+	wrapper, existed := m.plugins[config.PluginName]
+	if !existed {
+		wrapper = &WasmPluginWrapper{drainTimeout: defaultDrainTimeout}
+		m.plugins[config.PluginName] = wrapper
+	}
+	m.mux.Unlock()
+
+	wrapper.mux.Lock()
+	wrapper.config = config
+	if config.DrainTimeoutMs > 0 {
+		wrapper.drainTimeout = time.Duration(config.DrainTimeoutMs) * time.Millisecond
+	}
+	wrapper.mux.Unlock()
+
+	wrapper.notify(PluginStateLoading)
+
+	var module types.WasmModule
+	if addressed, ok := vm.(types.AddressedWasmVM); ok {
+		module = addressed.NewModuleAt(config.VmConfig.Address, code, abiVersion)
+	} else {
+		module = vm.NewModule(code, abiVersion)
+	}
+	if module == nil {
+		return fmt.Errorf("wasm plugin %s: compile wasm binary failed", config.PluginName)
+	}
+
+	instanceNum := config.InstanceNum
+	if instanceNum <= 0 {
+		instanceNum = 1
+	}
+
+	instances := make([]types.WasmInstance, 0, instanceNum)
+	for i := 0; i < instanceNum; i++ {
+		instances = append(instances, module.NewInstance())
+	}
+
+	if err := warmUp(instances, abiVersion); err != nil {
+		return fmt.Errorf("wasm plugin %s: %v", config.PluginName, err)
+	}
+
+	generation := &pluginGeneration{
+		plugin: &wasmPlugin{module: module, instances: instances},
+		state:  PluginStateReady,
+	}
+
+	wrapper.mux.Lock()
+	previous := wrapper.current
+	wrapper.current = generation
+	if wrapper.sharedData == nil {
+		wrapper.sharedData = newSharedDataStore()
+	}
+	if wrapper.sharedQueue == nil {
+		wrapper.sharedQueue = newSharedQueue()
+	}
+	wrapper.mux.Unlock()
+
+	wrapper.notify(PluginStateReady)
+
+	if previous != nil {
+		wrapper.startDraining(previous)
+	}
+
+	log.DefaultLogger.Infof("[wasm] plugin %s loaded, engine=%s instances=%d", config.PluginName, config.VmConfig.Engine, instanceNum)
+	return nil
+}
+
+// warmUp creates a root context on each instance and runs ProxyOnConfigure/ProxyOnVmStart, so
+// a newly compiled generation is fully initialized before AddOrUpdateWasm switches traffic to
+// it.
+func warmUp(instances []types.WasmInstance, abiVersion string) error {
+	for i, instance := range instances {
+		instanceABI := abi.GetABI(instance, abiVersion)
+		if instanceABI == nil {
+			return fmt.Errorf("unknown abi version %s", abiVersion)
+		}
+
+		instanceABI.SetImports(&proxywasm_0_1_0.DefaultInstanceCallback{})
+
+		exports, ok := instanceABI.GetExports().(proxywasm_0_1_0.Exports)
+		if !ok {
+			return fmt.Errorf("abi %s: exports do not implement proxywasm_0_1_0.Exports", abiVersion)
+		}
+
+		if !instance.Acquire(instanceABI) {
+			return fmt.Errorf("instance %d: already stopped", i)
+		}
+
+		rootContextID := int32(i + 1)
+		err := exports.ProxyOnContextCreate(rootContextID, 0)
+		if err == nil {
+			_, err = exports.ProxyOnConfigure(rootContextID, 0)
+		}
+		if err == nil {
+			_, err = exports.ProxyOnVmStart(rootContextID, 0)
+		}
+
+		instance.Release()
+
+		if err != nil {
+			return fmt.Errorf("instance %d: warm up: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// GetWasmPluginWrapperByName returns the wrapper for pluginName, or nil if unknown.
+func (m *WasmManager) GetWasmPluginWrapperByName(pluginName string) *WasmPluginWrapper {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	return m.plugins[pluginName]
+}